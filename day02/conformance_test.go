@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kaworu/adventofcode-2019/intcode/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	run := func(program, _ []int64) ([]int64, []int64, error) {
+		mem := make(Memory, len(program))
+		for i, x := range program {
+			mem[i] = Intcode(x)
+		}
+		if err := mem.Execute(); err != nil {
+			return nil, nil, err
+		}
+		final := make([]int64, len(mem))
+		for i, x := range mem {
+			final[i] = int64(x)
+		}
+		return nil, final, nil
+	}
+	conformance.Test(t, conformance.MachineFunc(run), conformance.Day02)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -83,34 +84,32 @@ func main() {
 		log.Fatalf("input error: %s\n", err)
 	}
 
-	// execute each possible noun and verb combination in its own goroutine
-	// using a couple of channel so that programs finding an interesting output
-	// can be collected.
-	alarm := make(chan Memory, 1)
-	landing := make(chan Memory, 1)
-	for noun := 0; noun < 100; noun++ {
-		for verb := 0; verb < 100; verb++ {
-			mem := initial.Copy()
-			go func(noun, verb Intcode) { // capture noun and verb
-				mem.Setup(noun, verb)
-				err := mem.Execute()
-				switch {
-				case err != nil:
-					// we crashed the program. Maybe that's because of this
-					// noun and verb combination, so we ignore it.
-				case noun == 12 && verb == 2:
-					alarm <- mem // 1202 program alarm
-				case mem[Output] == 19690720:
-					landing <- mem // Moon landing by Appollo 11
-				}
-			}(Intcode(noun), Intcode(verb))
-		}
+	// part one - restore the gravity assist program to the "1202 program
+	// alarm" state, i.e. a search with a single candidate.
+	alarm := Search{
+		Nouns: Range{12, 13},
+		Verbs: Range{2, 3},
+		Match: func(Memory) bool { return true },
+	}
+	fst, err := alarm.Run(context.Background(), initial)
+	if err != nil {
+		log.Fatalf("1202 program alarm: %s\n", err)
+	}
+	// part two - find the noun and verb producing the Moon landing date by
+	// Appollo 11.
+	landing := Search{
+		Nouns: Range{0, 100},
+		Verbs: Range{0, 100},
+		Match: func(mem Memory) bool { return mem[Output] == 19690720 },
+	}
+	snd, err := landing.Run(context.Background(), initial)
+	if err != nil {
+		log.Fatalf("landing search (%d tried, %d crashed): %s\n", snd.Tried, snd.Crashed, err)
 	}
-	fst := <-alarm
-	snd := <-landing
+	m := snd.Matches[0]
 
-	fmt.Printf("The value left at position 0 after the program halts is %d,\n", fst[Output])
-	fmt.Printf("and when the output is 19690720: 100 * noun + verb = %d.\n", 100*snd[Noun]+snd[Verb])
+	fmt.Printf("The value left at position 0 after the program halts is %d,\n", fst.Matches[0].Memory[Output])
+	fmt.Printf("and when the output is 19690720: 100 * noun + verb = %d.\n", 100*m.Noun+m.Verb)
 }
 
 // Parse an Intcode program.
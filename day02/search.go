@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ErrNoMatch is returned by Search.Run when no candidate matched.
+var ErrNoMatch = errors.New("no matching noun and verb")
+
+// Range is the half-open range of Intcode [From, To).
+type Range struct {
+	From, To Intcode
+}
+
+// Candidate is a noun and verb combination along with the program's final
+// memory when executed with it.
+type Candidate struct {
+	Noun, Verb Intcode
+	Memory     Memory
+}
+
+// Search look for the noun and verb combinations making a program halt with
+// a final memory satisfying Match. Candidates are ordered by noun, then verb.
+type Search struct {
+	Nouns, Verbs Range
+	// Match is the predicate on the final memory of the program.
+	Match func(mem Memory) bool
+	// Workers is the count of goroutines executing candidates. When zero,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+	// All tells whether every matching candidate should be found. When false,
+	// the search stop at the first matching candidate.
+	All bool
+}
+
+// SearchResult is the outcome of a Search.
+type SearchResult struct {
+	// Matches are the matching candidates in order. When not searching for
+	// all of them, it is only the first matching candidate.
+	Matches []Candidate
+	// Tried is the count of candidates executed.
+	Tried int
+	// Crashed is the count of candidates whose program crashed.
+	Crashed int
+	// Crashes count the candidates crashed by reason.
+	Crashes map[string]int
+}
+
+// Run the Search on the given initial memory, which is left unmodified. When
+// looking for the first match only, the remaining workers are stopped as soon
+// as it is found. It returns the search result and an error when no candidate
+// matched or when ctx is done before the search completed.
+func (s Search) Run(ctx context.Context, initial Memory) (SearchResult, error) {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	verbs := int(s.Verbs.To - s.Verbs.From)
+	total := int(s.Nouns.To-s.Nouns.From) * verbs
+	if verbs <= 0 || total <= 0 {
+		return SearchResult{}, ErrNoMatch
+	}
+
+	var mu sync.Mutex
+	res := SearchResult{Crashes: make(map[string]int)}
+	next := 0      // index of the next candidate to try
+	first := total // index of the first match found, total if none
+	// take returns the index of the next candidate to try and true, or false
+	// when there is none left.
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= total || (!s.All && next > first) {
+			return 0, false
+		}
+		i := next
+		next++
+		res.Tried++
+		return i, true
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i, ok := take()
+				if !ok {
+					return
+				}
+				noun := s.Nouns.From + Intcode(i/verbs)
+				verb := s.Verbs.From + Intcode(i%verbs)
+				mem, err := run(initial, noun, verb)
+				matched := err == nil && s.Match(mem)
+				mu.Lock()
+				switch {
+				case err != nil:
+					res.Crashed++
+					res.Crashes[err.Error()]++
+				case matched && s.All:
+					res.Matches = append(res.Matches, Candidate{noun, verb, mem})
+				case matched && i < first:
+					first = i
+					res.Matches = []Candidate{{noun, verb, mem}}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if len(res.Matches) == 0 {
+		return res, ErrNoMatch
+	}
+	sort.Slice(res.Matches, func(i, j int) bool {
+		a, b := res.Matches[i], res.Matches[j]
+		return a.Noun < b.Noun || (a.Noun == b.Noun && a.Verb < b.Verb)
+	})
+	return res, nil
+}
+
+// run execute a copy of initial setup with the given noun and verb. It
+// returns the final memory and an error when the program crashed.
+func run(initial Memory, noun, verb Intcode) (mem Memory, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			mem, err = nil, fmt.Errorf("crash: %v", r)
+		}
+	}()
+	mem = initial.Copy()
+	mem.Setup(noun, verb)
+	if err := mem.Execute(); err != nil {
+		return nil, err
+	}
+	return mem, nil
+}
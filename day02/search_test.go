@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	// Write mem[noun] + mem[verb] at address 0, crashing when either noun or
+	// verb is out of memory.
+	program := Memory{1, 0, 0, 0, 99, 10, 20, 30}
+	fifty := func(mem Memory) bool { return mem[Output] == 50 }
+
+	t.Run("all", func(t *testing.T) {
+		s := Search{Nouns: Range{0, 10}, Verbs: Range{0, 10}, Match: fifty, Workers: 4, All: true}
+		res, err := s.Run(context.Background(), program)
+		if err != nil {
+			t.Fatalf("Run() error: %s", err)
+		}
+		if len(res.Matches) != 2 ||
+			res.Matches[0].Noun != 6 || res.Matches[0].Verb != 7 ||
+			res.Matches[1].Noun != 7 || res.Matches[1].Verb != 6 {
+			t.Errorf("Run() matches = %v; want 6,7 and 7,6", res.Matches)
+		}
+		if res.Tried != 100 || res.Crashed != 36 {
+			t.Errorf("Run() tried %d and crashed %d; want 100 and 36", res.Tried, res.Crashed)
+		}
+		if program[Noun] != 0 || program[Verb] != 0 {
+			t.Errorf("Run() modified the initial memory: %v", program)
+		}
+	})
+
+	t.Run("first", func(t *testing.T) {
+		s := Search{Nouns: Range{0, 10}, Verbs: Range{0, 10}, Match: fifty, Workers: 4}
+		res, err := s.Run(context.Background(), program)
+		if err != nil {
+			t.Fatalf("Run() error: %s", err)
+		}
+		if len(res.Matches) != 1 || res.Matches[0].Noun != 6 || res.Matches[0].Verb != 7 {
+			t.Errorf("Run() matches = %v; want 6,7", res.Matches)
+		}
+		if res.Tried == 100 {
+			t.Errorf("Run() tried every candidate; want an early termination")
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		never := func(Memory) bool { return false }
+		s := Search{Nouns: Range{0, 10}, Verbs: Range{8, 10}, Match: never}
+		res, err := s.Run(context.Background(), program)
+		if !errors.Is(err, ErrNoMatch) {
+			t.Errorf("Run() error = %v; want %v", err, ErrNoMatch)
+		}
+		if res.Crashed != 20 || len(res.Crashes) == 0 {
+			t.Errorf("Run() crashed %d with reasons %v; want 20 crashes", res.Crashed, res.Crashes)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s := Search{Nouns: Range{0, 10}, Verbs: Range{0, 10}, Match: fifty}
+		if _, err := s.Run(ctx, program); !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() error = %v; want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kaworu/adventofcode-2019/intcode/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	run := func(program, input []int64) ([]int64, []int64, error) {
+		mem := make(Memory, len(program))
+		for i, x := range program {
+			mem[i] = Intcode(x)
+		}
+		in := make(Input, len(input))
+		for i, x := range input {
+			in[i] = Intcode(x)
+		}
+		out, err := mem.Execute(in)
+		if err != nil {
+			return nil, nil, err
+		}
+		output := make([]int64, len(out))
+		for i, x := range out {
+			output[i] = int64(x)
+		}
+		final := make([]int64, len(mem))
+		for i, x := range mem {
+			final[i] = int64(x)
+		}
+		return output, final, nil
+	}
+	conformance.Test(t, conformance.MachineFunc(run), conformance.Day05)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kaworu/adventofcode-2019/intcode/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	run := func(program, input []int64) ([]int64, []int64, error) {
+		in := make(chan Intcode, len(input))
+		for _, x := range input {
+			in <- Intcode(x)
+		}
+		out := make(chan Intcode)
+		amp := Amplifier{mem: make(Memory, len(program)), Input: in, Output: out}
+		for i, x := range program {
+			amp.mem[i] = Intcode(x)
+		}
+		halt := make(chan error, 1)
+		go func() {
+			halt <- amp.Execute()
+			close(out)
+		}()
+		var output []int64
+		for x := range out {
+			output = append(output, int64(x))
+		}
+		if err := <-halt; err != nil {
+			return nil, nil, err
+		}
+		final := make([]int64, len(amp.mem))
+		for i, x := range amp.mem {
+			final[i] = int64(x)
+		}
+		return output, final, nil
+	}
+	conformance.Test(t, conformance.MachineFunc(run), conformance.Day05)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Node is an Amplifier of a Graph.
+type Node struct {
+	Name string
+	// Phase is the Amplifier's phase setting, its first input.
+	Phase Intcode
+	// Inputs are given to the Amplifier right after its phase setting.
+	Inputs []Intcode
+	// Targets are the names of the Nodes and sinks receiving the Amplifier's
+	// output. Every target receive every output.
+	Targets []string
+}
+
+// Graph is a circuit of Amplifiers. Every Node's output is sent to all its
+// targets (fan-out), and a Node targeted by many others receive their outputs
+// interleaved (merge). Sinks are where the circuit's signals are collected.
+type Graph struct {
+	Nodes []Node
+	Sinks []string
+}
+
+// Ring returns the Graph of the feedback loop of Amplifiers configured
+// according to the given phase setting sequence, as run by FeedbackLoop. The
+// last Amplifier's output is collected by a sink named "thrusters".
+func Ring(seq []Intcode) *Graph {
+	n := len(seq)
+	g := &Graph{Nodes: make([]Node, n), Sinks: []string{"thrusters"}}
+	for i, phase := range seq {
+		g.Nodes[i] = Node{
+			Name:    fmt.Sprintf("amp%d", i),
+			Phase:   phase,
+			Targets: []string{fmt.Sprintf("amp%d", mod(i+1, n))},
+		}
+	}
+	g.Nodes[0].Inputs = []Intcode{0}
+	g.Nodes[n-1].Targets = append(g.Nodes[n-1].Targets, "thrusters")
+	return g
+}
+
+// ParseGraph read a Graph description. Each line either declare a sink:
+//
+//	sink NAME
+//
+// or a Node with its phase setting, initial inputs, and targets:
+//
+//	NAME PHASE [INPUT...] -> TARGET...
+//
+// Blank lines and everything following a # are ignored. It returns the Graph
+// and any read or syntax error encountered.
+func ParseGraph(r io.Reader) (*Graph, error) {
+	var g Graph
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "sink" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: expected sink NAME", lineno)
+			}
+			g.Sinks = append(g.Sinks, fields[1])
+			continue
+		}
+		n, err := parseNode(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+		g.Nodes = append(g.Nodes, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// parseNode parse the fields of a Node declaration line.
+func parseNode(fields []string) (Node, error) {
+	n := Node{Name: fields[0]}
+	arrow := -1
+	for i, f := range fields {
+		if f == "->" {
+			arrow = i
+			break
+		}
+	}
+	if arrow < 2 || arrow == len(fields)-1 {
+		return n, errors.New("expected NAME PHASE [INPUT...] -> TARGET...")
+	}
+	for i, f := range fields[1:arrow] {
+		x, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return n, err
+		}
+		if i == 0 {
+			n.Phase = Intcode(x)
+		} else {
+			n.Inputs = append(n.Inputs, Intcode(x))
+		}
+	}
+	n.Targets = fields[arrow+1:]
+	return n, nil
+}
+
+// Validate check that the Graph's names are unique and that every target is
+// either a Node or a sink. It returns an error describing the first problem
+// found.
+func (g *Graph) Validate() error {
+	names := make(map[string]bool)
+	for _, name := range g.Sinks {
+		if names[name] {
+			return fmt.Errorf("duplicate name %q", name)
+		}
+		names[name] = true
+	}
+	for _, n := range g.Nodes {
+		if names[n.Name] {
+			return fmt.Errorf("duplicate name %q", n.Name)
+		}
+		names[n.Name] = true
+	}
+	if len(g.Nodes) == 0 {
+		return errors.New("no amplifier")
+	}
+	if len(g.Sinks) == 0 {
+		return errors.New("no sink")
+	}
+	for _, n := range g.Nodes {
+		if len(n.Targets) == 0 {
+			return fmt.Errorf("amplifier %q has no target", n.Name)
+		}
+		for _, t := range n.Targets {
+			if !names[t] {
+				return fmt.Errorf("amplifier %q: unknown target %q", n.Name, t)
+			}
+		}
+	}
+	return nil
+}
+
+// Run the provided Amplifier Controller Software on every Node of the Graph
+// until they all halt. It returns the last signal received by each sink and
+// any error encountered, including a sink that received no signal.
+func (g *Graph) Run(apc Memory) (map[string]Intcode, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	var s Scheduler
+	inputs := make(map[string]chan Intcode)
+	for _, n := range g.Nodes {
+		c := make(chan Intcode, 2+len(n.Inputs))
+		c <- n.Phase
+		for _, x := range n.Inputs {
+			c <- x
+		}
+		inputs[n.Name] = c
+	}
+	signals := make(map[string]Intcode)
+	for _, n := range g.Nodes {
+		out := make(chan Intcode, 1)
+		amp := &Amplifier{mem: apc.Copy(), Input: inputs[n.Name], Output: out}
+		if _, err := s.Spawn(amp); err != nil {
+			return nil, err
+		}
+		var dsts []chan<- Intcode
+		var taps []func(Intcode)
+		for _, t := range n.Targets {
+			if c, ok := inputs[t]; ok {
+				dsts = append(dsts, c)
+				continue
+			}
+			sink := t
+			taps = append(taps, func(x Intcode) { signals[sink] = x })
+		}
+		s.Link(out, dsts, taps...)
+	}
+	if err := s.Run(); err != nil {
+		return nil, err
+	}
+	for _, name := range g.Sinks {
+		if _, ok := signals[name]; !ok {
+			return nil, fmt.Errorf("no signal for sink %q", name)
+		}
+	}
+	return signals, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			signals, err := Ring(tc.sequence).Run(tc.program)
+			if err != nil {
+				t.Fatalf("Run() error: %s", err)
+			}
+			if got := signals["thrusters"]; got != tc.want {
+				t.Errorf("thrusters signal = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGraph(t *testing.T) {
+	// Read the phase and a value, then Write phase + value.
+	add := Memory{3, 11, 3, 12, 1, 11, 12, 13, 4, 13, 99, 0, 0, 0}
+	// Read the phase and two values, then Write phase + value + value.
+	merge := Memory{3, 17, 3, 18, 3, 19, 1, 17, 18, 17, 1, 17, 19, 17, 4, 17, 99, 0, 0, 0}
+	tests := []struct {
+		name    string
+		program Memory
+		config  string
+		want    map[string]Intcode
+	}{
+		{
+			name:    "fan-out and multiple sinks",
+			program: add,
+			config: `
+				sink left
+				sink right # comment
+				A 1 10 -> B C
+				B 2 -> left
+				C 3 -> right`,
+			want: map[string]Intcode{"left": 13, "right": 14},
+		},
+		{
+			name:    "merge",
+			program: merge,
+			config: `
+				sink out
+				A 1 0 0 -> C
+				B 2 0 0 -> C
+				C 100 -> out`,
+			want: map[string]Intcode{"out": 103},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := ParseGraph(strings.NewReader(tc.config))
+			if err != nil {
+				t.Fatalf("ParseGraph() error: %s", err)
+			}
+			signals, err := g.Run(tc.program)
+			if err != nil {
+				t.Fatalf("Run() error: %s", err)
+			}
+			if !reflect.DeepEqual(signals, tc.want) {
+				t.Errorf("signals = %v; want %v", signals, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseGraphError(t *testing.T) {
+	tests := map[string]string{
+		"missing arrow":  "sink out\nA 5 out",
+		"missing phase":  "sink out\nA -> out",
+		"invalid input":  "sink out\nA 5 x -> out",
+		"unknown target": "sink out\nA 5 -> B",
+		"duplicate name": "sink A\nA 5 -> A",
+		"no sink":        "A 5 -> A",
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseGraph(strings.NewReader(config)); err == nil {
+				t.Errorf("ParseGraph() error = nil; want an error")
+			}
+		})
+	}
+}
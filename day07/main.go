@@ -3,12 +3,17 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strconv"
-	"sync"
+	"text/tabwriter"
+	"time"
 )
 
 const (
@@ -60,37 +65,14 @@ func mod(i, n int) int {
 	return ((i % n) + n) % n
 }
 
-// Permutations returns all permutations of the given set.
+// Permutations returns all permutations of the given set, in the order of
+// Heap's algorithm. See Permute to generate them lazily.
 func Permutations(set []Intcode) [][]Intcode {
-	// https://en.wikipedia.org/wiki/Heap%27s_algorithm
 	var all [][]Intcode
-	var generate func(k int, xs []Intcode)
-	generate = func(k int, xs []Intcode) {
-		if k == 1 {
-			p := make([]Intcode, len(xs))
-			copy(p, xs)
-			all = append(all, p)
-		} else {
-			generate(k-1, xs)
-			for i := 0; i < k-1; i++ {
-				if k%2 == 0 {
-					tmp := xs[i]
-					xs[i] = xs[k-1]
-					xs[k-1] = tmp
-				} else {
-					tmp := xs[0]
-					xs[0] = xs[k-1]
-					xs[k-1] = tmp
-				}
-				generate(k-1, xs)
-			}
-		}
+	c, _ := Permute(context.Background(), set, nil, HeapOrder) // no prefix, can't fail
+	for p := range c {
+		all = append(all, p)
 	}
-	// work on a local copy so that set is unmodified after this function
-	// returns.
-	cpy := make([]Intcode, len(set))
-	copy(cpy, set)
-	generate(len(cpy), cpy)
 	return all
 }
 
@@ -104,87 +86,143 @@ func (mem Memory) Copy() Memory {
 // Execute run the Intcode program in the Amplifier's memory.
 // It returns an error on failure.
 func (amp *Amplifier) Execute() error {
-	mem := amp.mem
 	for {
-		opcode := mem[amp.pc] % 100
-		lhsm := (mem[amp.pc] / 100) % 10  // first operand mode
-		rhsm := (mem[amp.pc] / 1000) % 10 // second operand mode
+		state, err := amp.step(true)
+		if err != nil || state == Halted {
+			return err
+		}
+	}
+}
+
+// step execute the instruction at the instruction pointer. When block is
+// false, step does not wait on the Amplifier's channels and returns either
+// Reading or Writing instead. It returns the Amplifier's State after the
+// instruction and an error on failure.
+func (amp *Amplifier) step(block bool) (State, error) {
+	mem := amp.mem
+	opcode := mem[amp.pc] % 100
+	lhsm := (mem[amp.pc] / 100) % 10  // first operand mode
+	rhsm := (mem[amp.pc] / 1000) % 10 // second operand mode
+	switch opcode {
+	case Add, Mult, LessThan, Equals, JumpIfTrue, JumpIfFalse:
+		lhs := mem[amp.pc+1]
+		if lhsm == Position {
+			lhs = mem[lhs]
+		}
+		rhs := mem[amp.pc+2]
+		if rhsm == Position {
+			rhs = mem[rhs]
+		}
+		dst := mem[amp.pc+3]
 		switch opcode {
-		case Add, Mult, LessThan, Equals, JumpIfTrue, JumpIfFalse:
-			lhs := mem[amp.pc+1]
-			if lhsm == Position {
-				lhs = mem[lhs]
+		case Add:
+			mem[dst] = lhs + rhs
+			amp.pc += 4
+		case Mult:
+			mem[dst] = lhs * rhs
+			amp.pc += 4
+		case LessThan:
+			if lhs < rhs {
+				mem[dst] = 1
+			} else {
+				mem[dst] = 0
 			}
-			rhs := mem[amp.pc+2]
-			if rhsm == Position {
-				rhs = mem[rhs]
+			amp.pc += 4
+		case Equals:
+			if lhs == rhs {
+				mem[dst] = 1
+			} else {
+				mem[dst] = 0
 			}
-			dst := mem[amp.pc+3]
-			switch opcode {
-			case Add:
-				mem[dst] = lhs + rhs
-				amp.pc += 4
-			case Mult:
-				mem[dst] = lhs * rhs
-				amp.pc += 4
-			case LessThan:
-				if lhs < rhs {
-					mem[dst] = 1
-				} else {
-					mem[dst] = 0
-				}
-				amp.pc += 4
-			case Equals:
-				if lhs == rhs {
-					mem[dst] = 1
-				} else {
-					mem[dst] = 0
-				}
-				amp.pc += 4
-			case JumpIfTrue:
-				if lhs != 0 {
-					amp.pc = int64(rhs)
-				} else {
-					amp.pc += 3
-				}
-			case JumpIfFalse:
-				if lhs == 0 {
-					amp.pc = int64(rhs)
-				} else {
-					amp.pc += 3
-				}
+			amp.pc += 4
+		case JumpIfTrue:
+			if lhs != 0 {
+				amp.pc = int64(rhs)
+			} else {
+				amp.pc += 3
 			}
-		case Read:
-			// NOTE: Read is always in position mode
-			dst := mem[amp.pc+1]
+		case JumpIfFalse:
+			if lhs == 0 {
+				amp.pc = int64(rhs)
+			} else {
+				amp.pc += 3
+			}
+		}
+	case Read:
+		// NOTE: Read is always in position mode
+		dst := mem[amp.pc+1]
+		if block {
 			mem[dst] = <-amp.Input
-			amp.pc += 2
-		case Write:
-			src := mem[amp.pc+1]
-			// NOTE: Write use the first mode for its only operand.
-			if lhsm == Position {
-				src = mem[src]
+		} else {
+			select {
+			case mem[dst] = <-amp.Input:
+			default:
+				return Reading, nil
 			}
+		}
+		amp.pc += 2
+	case Write:
+		src := mem[amp.pc+1]
+		// NOTE: Write use the first mode for its only operand.
+		if lhsm == Position {
+			src = mem[src]
+		}
+		if block {
 			amp.Output <- src
-			amp.pc += 2
-		case Halt:
-			return nil
-		default:
-			return fmt.Errorf("unsupported opcode: %d", opcode)
+		} else {
+			select {
+			case amp.Output <- src:
+			default:
+				return Writing, nil
+			}
 		}
+		amp.pc += 2
+	case Halt:
+		return Halted, nil
+	default:
+		return Halted, fmt.Errorf("unsupported opcode: %d", opcode)
 	}
+	return Running, nil
 }
 
 // FeedbackLoop run the provided Amplifier Controller Software on a feedback
 // loop of Amplifiers configured according to the given phase setting sequence.
-// It returns the last Amplifier's output.
-func FeedbackLoop(apc Memory, seq []Intcode) Intcode {
+// It returns the last Amplifier's output and any error encountered, wrapping
+// ErrDeadlock when the Amplifiers are all blocked.
+func FeedbackLoop(apc Memory, seq []Intcode) (Intcode, error) {
+	return FeedbackLoopTimeout(apc, seq, 0)
+}
+
+// FeedbackLoopTimeout is like FeedbackLoop but fails with an error wrapping
+// ErrTimeout when the Amplifiers did not halt within the given timeout. A
+// zero timeout means no timeout.
+func FeedbackLoopTimeout(apc Memory, seq []Intcode, timeout time.Duration) (Intcode, error) {
+	s := Scheduler{Timeout: timeout}
+	signal, _, err := feedbackLoop(&s, apc, seq)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Run(); err != nil {
+		return 0, err
+	}
+	return lastSignal(signal)
+}
+
+// feedbackLoop setup a feedback loop of Amplifiers configured according to
+// the given phase setting sequence to be run by s. It returns the channel from
+// where the last Amplifier's output can be read once s has run, the Tasks
+// running the Amplifiers in order, and any error encountered.
+func feedbackLoop(s *Scheduler, apc Memory, seq []Intcode) (<-chan Intcode, []*Task, error) {
 	n := len(seq)
 	amps := make([]Amplifier, n)
 	// Setup the feedback loop. i.e. each Amplifier to have its input being the
 	// previous Amplifier output. The channels are all setup such that the
 	// phase setting is provided first. The first Amplifier input is a special
 	// case where we have to additionally setup the initial input value zero.
+	// Note that part one require to setup the Amplifiers in series (not in a
+	// feedback loop), which works as long as the Amplifier Controller
+	// Software halt after receiving exactly one input (i.e. without looping)
+	// when the phase settings are between zero and four inclusive.
 	for i := range amps {
 		c := make(chan Intcode, 2)
 		c <- seq[i] // phase setting
@@ -196,72 +234,111 @@ func FeedbackLoop(apc Memory, seq []Intcode) Intcode {
 		amps[i].Input = c
 		amps[prev].Output = c
 	}
-	// Run each Amplifier in a goroutine and wait for all of them to halt.
-	// Note that part one require to setup the Amplifiers in series (not in a
-	// feedback loop).
-	var wg sync.WaitGroup
-	wg.Add(n)
+	tasks := make([]*Task, n)
 	for i := range amps {
-		i := i // capture i
-		go func() {
-			defer wg.Done()
-			// Ignore potential errors from the Amplifier here. Note that we
-			// also blindly trust the Amplifier Controller Software to halt
-			// after receiving exactly one input (i.e. without looping) for
-			// part one (i.e. when the phase settings are between zero and four
-			// inclusive). We could use an errgroup here but I'm trying to only
-			// use stdlib.
-			_ = amps[i].Execute()
-		}()
+		t, err := s.Spawn(&amps[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks[i] = t
 	}
-	wg.Wait()
 	// The first Amplifier input channel is the last Amplifier output channel.
-	return <-amps[0].Input
+	return amps[0].Input, tasks, nil
 }
 
-// HighestSignal run each possible phase setting sequences permutations in
-// a feedback loop of Amplifiers to find signals that can be sent to the
-// thruster.
-// It return the highest signal.
-func HighestSignal(apc Memory, phases []Intcode) Intcode {
-	sequences := Permutations(phases)
-	signals := make(chan Intcode, len(sequences))
-	for _, seq := range sequences {
-		seq := seq // capture seq
-		go func() {
-			signals <- FeedbackLoop(apc, seq)
-		}()
-	}
-	// Read every output values in order to find the greatest one to be
-	// returned.
-	var max Intcode
-	for i := 0; i < len(sequences); i++ {
-		x := <-signals
-		if i == 0 || x > max {
-			max = x
-		}
+// lastSignal returns the signal left in the given channel once every
+// Amplifier has halted, and an error if there is none.
+func lastSignal(c <-chan Intcode) (Intcode, error) {
+	select {
+	case x := <-c:
+		return x, nil
+	default:
+		return 0, errors.New("no signal from the last amplifier")
 	}
-	return max
 }
 
 // Main parse the Amplifier Controller Software Intcode program, and then run
 // each possible phase setting sequences permutations in a feedback loop of
 // Amplifiers to find the highest signal that can be sent to the thruster.
+// When a graph file is given, the Amplifiers circuit it describes is run
+// instead.
 func main() {
+	graph := flag.String("graph", "", "run the amplifiers circuit described in `file`")
+	table := flag.Bool("table", false, "display every phase setting sequence ranked by signal")
+	timeout := flag.Duration("timeout", 0, "fail when the amplifiers did not halt after `duration`, 0 for no timeout")
+	top := flag.Int("top", 0, "display only the `n` best phase setting sequences in the table, 0 for all")
+	flag.Parse()
 	// parse the puzzle input, i.e. the Amplifier Controller Software Intcode
 	// program.
 	mem, err := Parse(os.Stdin)
 	if err != nil {
 		log.Fatalf("input error: %s\n", err)
 	}
-	// part one - in series
-	ps := []Intcode{0, 1, 2, 3, 4} // phase settings
-	max := HighestSignal(mem, ps)
-	fmt.Printf("The highest signal that can be sent to the thrusters using the phase settings %v is %v.\n", ps, max)
-	// part two - feedback loop
-	ps = []Intcode{5, 6, 7, 8, 9}
-	max = HighestSignal(mem, ps)
-	fmt.Printf("The highest signal that can be sent to the thrusters using the phase settings %v is %v.\n", ps, max)
+	if *graph != "" {
+		runGraph(*graph, mem)
+		return
+	}
+	// part one - in series, part two - feedback loop
+	for _, ps := range [][]Intcode{{0, 1, 2, 3, 4}, {5, 6, 7, 8, 9}} {
+		res, err := HighestSignalTimeout(mem, ps, *timeout)
+		if err != nil {
+			log.Fatalf("HighestSignal(): %s\n", err)
+		}
+		if *table {
+			if err := printTable(os.Stdout, res, *top); err != nil {
+				log.Fatalf("table error: %s\n", err)
+			}
+		}
+		fmt.Printf("The highest signal that can be sent to the thrusters using the phase settings %v is %v.\n", ps, res.Best.Signal)
+	}
+}
+
+// printTable write the phase setting sequences of res ranked by signal into
+// out, limited to the top best ones unless top is zero. It returns any write
+// error encountered.
+func printTable(out io.Writer, res SignalResult, top int) error {
+	fmt.Fprintf(out, "%s\n", res.Stats)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', tabwriter.AlignRight)
+	// The error column is left aligned after a single space, like the
+	// failed rows.
+	fmt.Fprintf(w, "rank\tsequence\tsignal\tsteps\t error\n")
+	for i, r := range res.Sequences {
+		if top > 0 && i >= top {
+			break
+		}
+		if r.Failed() {
+			fmt.Fprintf(w, "%d\t%v\t-\t%d\t %s\n", i+1, r.Sequence, r.Steps, r.Errors[0])
+		} else {
+			fmt.Fprintf(w, "%d\t%v\t%d\t%d\t\n", i+1, r.Sequence, r.Signal, r.Steps)
+		}
+	}
+	return w.Flush()
+}
+
+// runGraph run the Amplifiers circuit described in the file at path and
+// display the signal received by each of its sinks.
+func runGraph(path string, apc Memory) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("graph error: %s\n", err)
+	}
+	g, err := ParseGraph(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("graph error: %s: %s\n", path, err)
+	}
+	signals, err := g.Run(apc)
+	if err != nil {
+		log.Fatalf("Run(): %s\n", err)
+	}
+	sinks := make([]string, 0, len(signals))
+	for name := range signals {
+		sinks = append(sinks, name)
+	}
+	sort.Strings(sinks)
+	for _, name := range sinks {
+		fmt.Printf("%s: %v\n", name, signals[name])
+	}
 }
 
 // Parse an Intcode program.
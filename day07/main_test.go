@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,7 +52,10 @@ func TestFeedbackLoop(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			output := FeedbackLoop(tc.program, tc.sequence)
+			output, err := FeedbackLoop(tc.program, tc.sequence)
+			if err != nil {
+				t.Fatalf("FeedbackLoop() error: %s", err)
+			}
 			if output != tc.want {
 				t.Errorf("output = %v; want %v", output, tc.want)
 			}
@@ -60,14 +67,97 @@ func TestHighestSignal(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			output := HighestSignal(tc.program, shuffled(tc.sequence))
-			if output != tc.want {
-				t.Errorf("output = %v; want %v", output, tc.want)
+			res, err := HighestSignal(tc.program, shuffled(tc.sequence))
+			if err != nil {
+				t.Fatalf("HighestSignal() error: %s", err)
+			}
+			if res.Best.Signal != tc.want {
+				t.Errorf("output = %v; want %v", res.Best.Signal, tc.want)
+			}
+			if !reflect.DeepEqual(res.Best.Sequence, tc.sequence) {
+				t.Errorf("best sequence = %v; want %v", res.Best.Sequence, tc.sequence)
+			}
+			if res.Stats.Sequences != 120 || res.Stats.Failed != 0 {
+				t.Errorf("stats = %v; want 120 sequences and none failed", res.Stats)
 			}
 		})
 	}
 }
 
+func TestHighestSignalErrors(t *testing.T) {
+	// Read the phase p and a value v, crash if p = 2 and v = 0, Write p + v
+	// otherwise.
+	program := Memory{
+		3, 30, 3, 31, 1002, 30, 10, 32, 1, 32, 31, 32, 1008, 32, 20, 33,
+		1005, 33, 29, 1, 30, 31, 34, 4, 34, 99, 0, 0, 0, 42, 0, 0, 0, 0, 0,
+	}
+	res, err := HighestSignal(program, []Intcode{2, 1})
+	if err != nil {
+		t.Fatalf("HighestSignal() error: %s", err)
+	}
+	if !reflect.DeepEqual(res.Best.Sequence, []Intcode{1, 2}) || res.Best.Signal != 3 {
+		t.Errorf("best = %v with %v; want [1 2] with 3", res.Best.Sequence, res.Best.Signal)
+	}
+	failed := res.Sequences[1]
+	if !failed.Failed() || failed.Errors[0].Index != 0 || failed.Errors[0].PC != 29 {
+		t.Errorf("failed sequence errors = %v; want the first amplifier to fail at pc=29", failed.Errors)
+	}
+	if res.Stats.Failed != 1 {
+		t.Errorf("stats = %v; want one failed sequence", res.Stats)
+	}
+
+	_, err = HighestSignal(program, []Intcode{2})
+	var aerr AmplifierError
+	if !errors.As(err, &aerr) {
+		t.Errorf("HighestSignal() error = %v; want an AmplifierError", err)
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	// see TestHighestSignalErrors
+	program := Memory{
+		3, 30, 3, 31, 1002, 30, 10, 32, 1, 32, 31, 32, 1008, 32, 20, 33,
+		1005, 33, 29, 1, 30, 31, 34, 4, 34, 99, 0, 0, 0, 42, 0, 0, 0, 0, 0,
+	}
+	res, err := HighestSignal(program, []Intcode{2, 1})
+	if err != nil {
+		t.Fatalf("HighestSignal() error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := printTable(&buf, res, 0); err != nil {
+		t.Fatalf("printTable() error: %s", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("printTable() =\n%s\nwant the stats, a header and two rows", buf.String())
+	}
+	want := []string{
+		"  rank  sequence  signal  steps error",
+		"     1     [1 2]       3     16",
+		"     2     [2 1]       -      7 amplifier 0 (pc=29 Halted): unsupported opcode: 42",
+	}
+	if !reflect.DeepEqual(lines[1:4], want) {
+		t.Errorf("printTable() =\n%s\nwant\n%s", strings.Join(lines[1:4], "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHighestSignalTie(t *testing.T) {
+	// Read the phase and a value, Write 7.
+	program := Memory{3, 9, 3, 9, 104, 7, 99, 0, 0, 0}
+	res, err := HighestSignal(program, []Intcode{2, 0, 1})
+	if err != nil {
+		t.Fatalf("HighestSignal() error: %s", err)
+	}
+	var got [][]Intcode
+	for _, r := range res.Sequences {
+		got = append(got, r.Sequence)
+	}
+	want := [][]Intcode{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sequences = %v; want %v", got, want)
+	}
+}
+
 // shuffled return a copy of the given slice with its element in a random
 // order. Note that rand.Seed() must has been called before this function.
 func shuffled(xs []Intcode) []Intcode {
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Orders
+const (
+	// HeapOrder is the order of Heap's algorithm, where each permutation is
+	// generated from the previous one by a single swap. It is the order of
+	// Permutations.
+	HeapOrder Order = iota
+	// Lexicographic is the ascending lexicographic order.
+	Lexicographic
+)
+
+// Order is the order in which permutations are generated.
+type Order uint8
+
+// SearchResult is the outcome of a Search.
+type SearchResult struct {
+	// Sequence is the phase setting sequence producing the highest signal,
+	// the first one in lexicographic order among those producing the same
+	// signal.
+	Sequence []Intcode
+	Signal   Intcode
+	// Evaluated is the count of sequences evaluated.
+	Evaluated int
+	// Failed is the count of sequences whose evaluation failed.
+	Failed int
+	// Pruned is the count of prefixes whose sequences were not evaluated
+	// because of their upper bound.
+	Pruned int
+}
+
+// Permute lazily generate every permutation of set starting with prefix, in
+// the given order. Each permutation is a newly allocated slice sent on the
+// returned channel, closed once every permutation has been sent or when ctx
+// is done. Elements of prefix must all be in set, and only the elements of
+// set not in prefix are permuted. It returns the channel and an error when
+// prefix is not a part of set.
+func Permute(ctx context.Context, set, prefix []Intcode, order Order) (<-chan []Intcode, error) {
+	rest, err := without(set, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if order == Lexicographic {
+		sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+	} else if order != HeapOrder {
+		return nil, fmt.Errorf("invalid order %d", order)
+	}
+	c := make(chan []Intcode)
+	go func() {
+		defer close(c)
+		// send a copy of rest prefixed by prefix. It returns false when ctx is
+		// done, true otherwise.
+		send := func() bool {
+			p := make([]Intcode, 0, len(prefix)+len(rest))
+			p = append(p, prefix...)
+			p = append(p, rest...)
+			select {
+			case c <- p:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if order == Lexicographic {
+			for ok := send(); ok && nextPermutation(rest); ok = send() {
+			}
+			return
+		}
+		// https://en.wikipedia.org/wiki/Heap%27s_algorithm, non-recursive
+		// version.
+		stack := make([]int, len(rest))
+		if !send() {
+			return
+		}
+		for i := 1; i < len(rest); {
+			if stack[i] < i {
+				if i%2 == 0 {
+					rest[0], rest[i] = rest[i], rest[0]
+				} else {
+					rest[stack[i]], rest[i] = rest[i], rest[stack[i]]
+				}
+				if !send() {
+					return
+				}
+				stack[i]++
+				i = 1
+			} else {
+				stack[i] = 0
+				i++
+			}
+		}
+	}()
+	return c, nil
+}
+
+// nextPermutation rearrange xs into its next permutation in lexicographic
+// order. It returns false when xs was the last permutation, true otherwise.
+func nextPermutation(xs []Intcode) bool {
+	i := len(xs) - 2
+	for i >= 0 && xs[i] >= xs[i+1] {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	j := len(xs) - 1
+	for xs[j] <= xs[i] {
+		j--
+	}
+	xs[i], xs[j] = xs[j], xs[i]
+	for l, r := i+1, len(xs)-1; l < r; l, r = l+1, r-1 {
+		xs[l], xs[r] = xs[r], xs[l]
+	}
+	return true
+}
+
+// without returns a copy of set without the elements of prefix, and an error
+// when an element of prefix is not in set.
+func without(set, prefix []Intcode) ([]Intcode, error) {
+	rest := make([]Intcode, len(set))
+	copy(rest, set)
+	for _, x := range prefix {
+		found := false
+		for i, y := range rest {
+			if x == y {
+				rest = append(rest[:i], rest[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("prefix %v is not a part of %v", prefix, set)
+		}
+	}
+	return rest, nil
+}
+
+// Search find the phase setting sequence, i.e. permutation of phases,
+// producing the highest signal according to eval using branch and bound.
+// Sequences are explored in lexicographic order, and before exploring the
+// sequences starting with a given prefix, bound is called with that prefix:
+// it must return an upper bound of the signal produced by any of these
+// sequences. The prefix is pruned when its bound is not greater than the best
+// signal found so far. When bound is nil, every sequence is evaluated.
+// Sequences whose evaluation fails are skipped. It returns the search result
+// and an error when every evaluation failed.
+func Search(phases []Intcode, eval func(seq []Intcode) (Intcode, error), bound func(prefix []Intcode) Intcode) (SearchResult, error) {
+	var res SearchResult
+	var firstErr error
+	found := false
+	set := make([]Intcode, len(phases))
+	copy(set, phases)
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+	used := make([]bool, len(set))
+	seq := make([]Intcode, 0, len(set))
+
+	var explore func()
+	explore = func() {
+		if len(seq) == len(set) {
+			res.Evaluated++
+			signal, err := eval(seq)
+			switch {
+			case err != nil:
+				res.Failed++
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%v: %w", seq, err)
+				}
+			case !found || signal > res.Signal:
+				found = true
+				res.Signal = signal
+				res.Sequence = append([]Intcode(nil), seq...)
+			}
+			return
+		}
+		if found && len(seq) > 0 && bound != nil && bound(seq) <= res.Signal {
+			res.Pruned++
+			return
+		}
+		for i, x := range set {
+			// skip used elements and duplicates already explored at this
+			// position.
+			if used[i] || (i > 0 && set[i-1] == x && !used[i-1]) {
+				continue
+			}
+			used[i] = true
+			seq = append(seq, x)
+			explore()
+			seq = seq[:len(seq)-1]
+			used[i] = false
+		}
+	}
+	explore()
+
+	if !found {
+		if firstErr == nil {
+			firstErr = errors.New("no phase setting sequence")
+		}
+		return res, firstErr
+	}
+	return res, nil
+}
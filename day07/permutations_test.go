@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// collect returns every permutation received from c.
+func collect(c <-chan []Intcode) [][]Intcode {
+	var all [][]Intcode
+	for p := range c {
+		all = append(all, p)
+	}
+	return all
+}
+
+func TestPermute(t *testing.T) {
+	ctx := context.Background()
+	set := []Intcode{3, 1, 2}
+
+	t.Run("heap order", func(t *testing.T) {
+		c, err := Permute(ctx, set, nil, HeapOrder)
+		if err != nil {
+			t.Fatalf("Permute() error: %s", err)
+		}
+		want := [][]Intcode{{3, 1, 2}, {1, 3, 2}, {2, 3, 1}, {3, 2, 1}, {1, 2, 3}, {2, 1, 3}}
+		if got := collect(c); !reflect.DeepEqual(got, want) {
+			t.Errorf("Permute() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("lexicographic order", func(t *testing.T) {
+		c, err := Permute(ctx, set, nil, Lexicographic)
+		if err != nil {
+			t.Fatalf("Permute() error: %s", err)
+		}
+		want := [][]Intcode{{1, 2, 3}, {1, 3, 2}, {2, 1, 3}, {2, 3, 1}, {3, 1, 2}, {3, 2, 1}}
+		if got := collect(c); !reflect.DeepEqual(got, want) {
+			t.Errorf("Permute() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		c, err := Permute(ctx, []Intcode{5, 6, 7, 8}, []Intcode{7, 5}, Lexicographic)
+		if err != nil {
+			t.Fatalf("Permute() error: %s", err)
+		}
+		want := [][]Intcode{{7, 5, 6, 8}, {7, 5, 8, 6}}
+		if got := collect(c); !reflect.DeepEqual(got, want) {
+			t.Errorf("Permute() = %v; want %v", got, want)
+		}
+		if _, err := Permute(ctx, set, []Intcode{4}, HeapOrder); err == nil {
+			t.Errorf("Permute() with an invalid prefix error = nil; want an error")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		// 12! permutations, way too many to be generated.
+		c, err := Permute(ctx, []Intcode{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, nil, HeapOrder)
+		if err != nil {
+			t.Fatalf("Permute() error: %s", err)
+		}
+		for i := 0; i < 1000; i++ {
+			<-c
+		}
+		cancel()
+		for range c {
+			// drain until the generator notice the cancellation.
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			eval := func(seq []Intcode) (Intcode, error) {
+				return FeedbackLoop(tc.program, seq)
+			}
+			full, err := Search(tc.sequence, eval, nil)
+			if err != nil {
+				t.Fatalf("Search() error: %s", err)
+			}
+			if full.Signal != tc.want || !reflect.DeepEqual(full.Sequence, tc.sequence) {
+				t.Errorf("Search() = %v with %v; want %v with %v", full.Sequence, full.Signal, tc.sequence, tc.want)
+			}
+			if full.Evaluated != 120 || full.Pruned != 0 {
+				t.Errorf("Search() evaluated %d and pruned %d; want 120 and 0", full.Evaluated, full.Pruned)
+			}
+		})
+	}
+}
+
+func TestSearchPruning(t *testing.T) {
+	// The signal is the reversed sequence read as a decimal number, so that
+	// the best sequence is the first explored and an upper bound is the
+	// prefix followed by the remaining digits in increasing order.
+	number := func(seq []Intcode) Intcode {
+		var n Intcode
+		for i := len(seq) - 1; i >= 0; i-- {
+			n = 10*n + seq[i]
+		}
+		return n
+	}
+	phases := []Intcode{4, 2, 3, 5, 1}
+	bound := func(prefix []Intcode) Intcode {
+		rest, _ := without([]Intcode{1, 2, 3, 4, 5}, prefix)
+		return number(append(prefix[:len(prefix):len(prefix)], rest...))
+	}
+	eval := func(seq []Intcode) (Intcode, error) { return number(seq), nil }
+	res, err := Search(phases, eval, bound)
+	if err != nil {
+		t.Fatalf("Search() error: %s", err)
+	}
+	if res.Signal != 54321 || !reflect.DeepEqual(res.Sequence, []Intcode{1, 2, 3, 4, 5}) {
+		t.Errorf("Search() = %v with %v; want [1 2 3 4 5] with 54321", res.Sequence, res.Signal)
+	}
+	if res.Evaluated >= 120 || res.Pruned == 0 {
+		t.Errorf("Search() evaluated %d and pruned %d; want some pruning", res.Evaluated, res.Pruned)
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// States
+const (
+	// Running is the state of an Amplifier able to execute its next
+	// instruction.
+	Running State = iota
+	// Reading is the state of an Amplifier blocked on a Read instruction
+	// because its Input channel is empty.
+	Reading
+	// Writing is the state of an Amplifier blocked on a Write instruction
+	// because its Output channel is full.
+	Writing
+	// Halted is the state of an Amplifier that has stopped, either because
+	// it reached the Halt instruction or because of an error.
+	Halted
+)
+
+// DefaultQuantum is the maximum count of instructions executed by an
+// Amplifier before giving back its worker to the Scheduler.
+const DefaultQuantum = 1024
+
+// State represent the execution state of an Amplifier.
+type State uint8
+
+// Task is an Amplifier run by a Scheduler.
+type Task struct {
+	id    int // spawn order
+	amp   *Amplifier
+	state State
+	err   error
+	cause error // why the Task did not halt, see Cause
+	steps int   // count of instructions executed
+}
+
+// link forward every value from a channel to many channels and functions. A
+// value is read from src only once it has been delivered everywhere.
+type link struct {
+	src     <-chan Intcode
+	dsts    []chan<- Intcode
+	taps    []func(Intcode)
+	value   Intcode // value being delivered
+	pending []bool  // destinations value has yet to be delivered to, nil if none
+}
+
+// Scheduler run many Amplifiers cooperatively on a fixed pool of workers.
+// Amplifiers blocked on their Input or Output channel are parked until
+// another Amplifier respectively write to or read from the channel. The zero
+// value is ready to use.
+type Scheduler struct {
+	// Workers is the count of goroutines running the Amplifiers. When zero,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+	// Quantum is the maximum count of instructions executed by an Amplifier
+	// before being rescheduled. When zero, DefaultQuantum is used.
+	Quantum int
+	// Timeout is the maximum duration of Run, after which the Amplifiers
+	// still running are stopped. It allows to detect Amplifiers that never
+	// halt without blocking. When zero, Run never times out.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []*Task
+	runq    []*Task
+	readers map[uintptr][]*Task // Reading tasks by Input channel
+	writers map[uintptr][]*Task // Writing tasks by Output channel
+	running int                 // count of tasks currently executed
+	links   []*link
+	stopped bool // set when Timeout has elapsed
+}
+
+// SchedulerError is the error returned by Scheduler.Run. It gathers every
+// Amplifier that failed and every Amplifier that did not halt, either left
+// blocked by a deadlock or stopped by the Timeout.
+type SchedulerError struct {
+	Failed []*Task // Amplifiers halted on error
+	// Blocked are the Amplifiers that did not halt, see Task.Cause for the
+	// reason of each.
+	Blocked  []*Task
+	TimedOut bool // whether the Scheduler's Timeout has elapsed
+}
+
+var (
+	// ErrDeadlock is wrapped by a SchedulerError when every Amplifier that
+	// has not halted is blocked.
+	ErrDeadlock = errors.New("deadlock: all amplifiers are blocked")
+	// ErrTimeout is wrapped by a SchedulerError when the Scheduler's Timeout
+	// has elapsed before every Amplifier halted.
+	ErrTimeout = errors.New("timeout: amplifiers did not halt in time")
+)
+
+// String implements Stringer for State.
+func (s State) String() string {
+	switch s {
+	case Running:
+		return "Running"
+	case Reading:
+		return "Reading"
+	case Writing:
+		return "Writing"
+	case Halted:
+		return "Halted"
+	default:
+		return "<invalid state>"
+	}
+}
+
+// Amplifier returns the Amplifier run by the Task.
+func (t *Task) Amplifier() *Amplifier {
+	return t.amp
+}
+
+// State returns the Task's current state.
+func (t *Task) State() State {
+	return t.state
+}
+
+// Steps returns the count of instructions executed by the Task's Amplifier.
+func (t *Task) Steps() int {
+	return t.steps
+}
+
+// String implements Stringer for Task. It describes the Amplifier's
+// instruction pointer and the channel it is blocked on, if any.
+func (t *Task) String() string {
+	desc := fmt.Sprintf("amplifier %d (pc=%d)", t.id, t.amp.pc)
+	switch t.state {
+	case Reading:
+		return fmt.Sprintf("%s blocked reading its empty input", desc)
+	case Writing:
+		return fmt.Sprintf("%s blocked writing its full output (%d/%d)", desc, len(t.amp.Output), cap(t.amp.Output))
+	case Running:
+		return fmt.Sprintf("%s still running", desc)
+	default:
+		return fmt.Sprintf("%s halted", desc)
+	}
+}
+
+// Err returns the error that halted the Task, nil if it did not fail.
+func (t *Task) Err() error {
+	return t.err
+}
+
+// Cause returns why the Task did not halt once its Scheduler's Run returned:
+// ErrDeadlock when it was left blocked by a deadlock, ErrTimeout when it was
+// stopped by the Timeout while it could still make progress, nil when it
+// halted.
+func (t *Task) Cause() error {
+	return t.cause
+}
+
+// Spawn add an Amplifier to be run by the Scheduler. Because a parked
+// Amplifier never wait on its channels, both of them must be buffered. It
+// returns the Task running amp and an error when amp can not be scheduled.
+func (s *Scheduler) Spawn(amp *Amplifier) (*Task, error) {
+	if cap(amp.Input) == 0 || cap(amp.Output) == 0 {
+		return nil, errors.New("scheduled amplifiers channels must be buffered")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &Task{id: len(s.tasks), amp: amp, state: Running}
+	s.tasks = append(s.tasks, t)
+	s.runq = append(s.runq, t)
+	return t, nil
+}
+
+// Link connect the src channel to every dsts channels, so that each value
+// written to src by an Amplifier is written to all of dsts. Every tap is
+// called with each value once it has been written to dsts. It allows
+// Amplifiers to fan-out, which they can not do with a single Output channel.
+func (s *Scheduler) Link(src <-chan Intcode, dsts []chan<- Intcode, taps ...func(Intcode)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links = append(s.links, &link{src: src, dsts: dsts, taps: taps})
+}
+
+// Tasks returns every Task spawned so far.
+func (s *Scheduler) Tasks() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tasks
+}
+
+// Run execute every spawned Amplifier until they all halt, are all blocked,
+// or until the Timeout has elapsed. It returns nil when every Amplifier
+// halted successfully, a *SchedulerError otherwise.
+func (s *Scheduler) Run() error {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	s.mu.Lock()
+	s.cond = sync.NewCond(&s.mu)
+	s.readers = make(map[uintptr][]*Task)
+	s.writers = make(map[uintptr][]*Task)
+	s.stopped = false
+	s.mu.Unlock()
+	if s.Timeout > 0 {
+		timer := time.AfterFunc(s.Timeout, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.stopped = true
+			s.cond.Broadcast()
+		})
+		defer timer.Stop()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			s.work()
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	serr := SchedulerError{TimedOut: s.stopped}
+	live := s.live()
+	for _, t := range s.tasks {
+		t.cause = nil
+		switch {
+		case t.err != nil:
+			serr.Failed = append(serr.Failed, t)
+		case t.state == Halted:
+		case live[t]:
+			t.cause = ErrTimeout
+			serr.Blocked = append(serr.Blocked, t)
+		default:
+			t.cause = ErrDeadlock
+			serr.Blocked = append(serr.Blocked, t)
+		}
+	}
+	if len(serr.Failed) > 0 || len(serr.Blocked) > 0 {
+		return &serr
+	}
+	return nil
+}
+
+// live returns the tasks that could still make progress when Run stopped,
+// i.e. the tasks connected through their channels and the links to a runnable
+// task. The other tasks not halted are all parked on each other, which is a
+// deadlock. It must be called with s.mu held.
+func (s *Scheduler) live() map[*Task]bool {
+	// union-find of the channels, connected by the tasks and the links.
+	parent := make(map[uintptr]uintptr)
+	find := func(id uintptr) uintptr {
+		for {
+			p, ok := parent[id]
+			if !ok || p == id {
+				return id
+			}
+			id = p
+		}
+	}
+	union := func(a, b uintptr) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[ra] = rb
+		}
+	}
+	for _, t := range s.tasks {
+		union(chanID(t.amp.Input), chanID(t.amp.Output))
+	}
+	for _, l := range s.links {
+		for _, dst := range l.dsts {
+			union(chanID(l.src), chanID(dst))
+		}
+	}
+	runnable := make(map[uintptr]bool)
+	for _, t := range s.tasks {
+		if t.state == Running {
+			runnable[find(chanID(t.amp.Input))] = true
+		}
+	}
+	live := make(map[*Task]bool)
+	for _, t := range s.tasks {
+		live[t] = runnable[find(chanID(t.amp.Input))]
+	}
+	return live
+}
+
+// work is a Scheduler worker loop, executing tasks from the run queue until
+// there is no more work to be done.
+func (s *Scheduler) work() {
+	quantum := s.Quantum
+	if quantum <= 0 {
+		quantum = DefaultQuantum
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for len(s.runq) == 0 && s.running > 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if len(s.runq) == 0 || s.stopped {
+			// Nothing is running and nothing can run: either every task has
+			// halted or we have a deadlock. Otherwise we have timed out.
+			s.cond.Broadcast()
+			return
+		}
+		t := s.runq[0]
+		s.runq = s.runq[1:]
+		s.running++
+		s.mu.Unlock()
+		n, state, err := t.run(quantum)
+		s.mu.Lock()
+		s.running--
+		t.state, t.err = state, err
+		t.steps += n
+		switch state {
+		case Running:
+			s.runq = append(s.runq, t)
+		case Reading:
+			s.park(s.readers, chanID(t.amp.Input), t)
+		case Writing:
+			s.park(s.writers, chanID(t.amp.Output), t)
+		}
+		if n > 0 {
+			// t may have written to its Output and read from its Input, so
+			// wake up the tasks waiting on them.
+			s.wake(s.readers, chanID(t.amp.Output))
+			s.wake(s.writers, chanID(t.amp.Input))
+			s.pump()
+		}
+		s.cond.Broadcast()
+	}
+}
+
+// park the given blocked task on the channel identified by id. The channel is
+// checked again while s.mu is held so that a write or read that happened
+// after t blocked is not missed.
+func (s *Scheduler) park(parked map[uintptr][]*Task, id uintptr, t *Task) {
+	if t.ready() {
+		t.state = Running
+		s.runq = append(s.runq, t)
+		return
+	}
+	parked[id] = append(parked[id], t)
+}
+
+// wake every task parked on the channel identified by id.
+func (s *Scheduler) wake(parked map[uintptr][]*Task, id uintptr) {
+	for _, t := range parked[id] {
+		t.state = Running
+		s.runq = append(s.runq, t)
+	}
+	delete(parked, id)
+}
+
+// pump forward values through the links until none of them can make
+// progress, waking up the tasks blocked on the channels involved. It must be
+// called with s.mu held.
+func (s *Scheduler) pump() {
+	for progress := true; progress; {
+		progress = false
+		for _, l := range s.links {
+			for l.forward() {
+				progress = true
+				s.wake(s.writers, chanID(l.src))
+				for _, dst := range l.dsts {
+					s.wake(s.readers, chanID(dst))
+				}
+			}
+		}
+	}
+}
+
+// forward try to deliver the link's current value, reading the next one from
+// src when there is none. Channels are never waited on, as other Amplifiers
+// may be using them concurrently. It returns true when a value was read or
+// delivered, false otherwise.
+func (l *link) forward() bool {
+	progress := false
+	if l.pending == nil {
+		select {
+		case l.value = <-l.src:
+			l.pending = make([]bool, len(l.dsts))
+			for i := range l.pending {
+				l.pending[i] = true
+			}
+			progress = true
+		default:
+			return false
+		}
+	}
+	done := true
+	for i, dst := range l.dsts {
+		if !l.pending[i] {
+			continue
+		}
+		select {
+		case dst <- l.value:
+			l.pending[i] = false
+			progress = true
+		default:
+			done = false
+		}
+	}
+	if done {
+		for _, tap := range l.taps {
+			tap(l.value)
+		}
+		l.pending = nil
+	}
+	return progress
+}
+
+// run execute up to quantum instructions of the Task's Amplifier. It returns
+// the count of instructions executed, the Amplifier's state and any error
+// encountered, including crashes.
+func (t *Task) run(quantum int) (n int, state State, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			state, err = Halted, fmt.Errorf("amplifier crashed at pc=%d: %v", t.amp.pc, r)
+		}
+	}()
+	for n = 0; n < quantum; n++ {
+		state, err = t.amp.step(false)
+		if err != nil || state != Running {
+			return n, state, err
+		}
+	}
+	return n, Running, nil
+}
+
+// ready tells whether the blocked Task can make progress.
+func (t *Task) ready() bool {
+	switch t.state {
+	case Reading:
+		return len(t.amp.Input) > 0
+	case Writing:
+		return len(t.amp.Output) < cap(t.amp.Output)
+	default:
+		return true
+	}
+}
+
+// chanID returns an identifier for the channel c shared by every directional
+// view of it, e.g. an Amplifier's Output and the next Amplifier's Input.
+func chanID(c interface{}) uintptr {
+	return reflect.ValueOf(c).Pointer()
+}
+
+// Error implements the error interface for SchedulerError.
+func (e *SchedulerError) Error() string {
+	var msgs []string
+	for _, t := range e.Failed {
+		msgs = append(msgs, t.err.Error())
+	}
+	for _, cause := range []error{ErrDeadlock, ErrTimeout} {
+		var blocked []string
+		for _, t := range e.Blocked {
+			if t.cause == cause {
+				blocked = append(blocked, t.String())
+			}
+		}
+		if len(blocked) > 0 {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", cause, strings.Join(blocked, ", ")))
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrTimeout when an Amplifier was stopped by the Timeout,
+// ErrDeadlock when Amplifiers were left blocked by a deadlock, the first
+// Amplifier's error otherwise.
+func (e *SchedulerError) Unwrap() error {
+	for _, t := range e.Blocked {
+		if t.cause == ErrTimeout {
+			return ErrTimeout
+		}
+	}
+	if len(e.Blocked) > 0 {
+		return ErrDeadlock
+	}
+	if len(e.Failed) > 0 {
+		return e.Failed[0].err
+	}
+	return nil
+}
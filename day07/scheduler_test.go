@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRun(t *testing.T) {
+	// Read a value and Write it back doubled.
+	double := Memory{3, 9, 1002, 9, 2, 9, 4, 9, 99, 0}
+	s := Scheduler{Workers: 4, Quantum: 2}
+	n := 1000
+	outputs := make([]chan Intcode, n)
+	for i := range outputs {
+		in := make(chan Intcode, 1)
+		outputs[i] = make(chan Intcode, 1)
+		in <- Intcode(i)
+		if _, err := s.Spawn(&Amplifier{mem: double.Copy(), Input: in, Output: outputs[i]}); err != nil {
+			t.Fatalf("Spawn() error: %s", err)
+		}
+	}
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error: %s", err)
+	}
+	for i, c := range outputs {
+		if x := <-c; x != Intcode(2*i) {
+			t.Errorf("amplifier %d output = %v; want %v", i, x, 2*i)
+		}
+	}
+	for _, task := range s.Tasks() {
+		if task.State() != Halted {
+			t.Errorf("task state = %v; want %v", task.State(), Halted)
+		}
+	}
+}
+
+func TestSchedulerDeadlock(t *testing.T) {
+	// Read a value and Write it back, forever.
+	echo := Memory{3, 5, 4, 5, 1105, 1, 0}
+	// With nothing in the ring, everyone is blocked.
+	var s Scheduler
+	a, b := make(chan Intcode, 1), make(chan Intcode, 1)
+	for _, amp := range []*Amplifier{
+		{mem: echo.Copy(), Input: a, Output: b},
+		{mem: echo.Copy(), Input: b, Output: a},
+		{mem: Memory{99}, Input: make(chan Intcode, 1), Output: make(chan Intcode, 1)},
+	} {
+		if _, err := s.Spawn(amp); err != nil {
+			t.Fatalf("Spawn() error: %s", err)
+		}
+	}
+	err := s.Run()
+	var serr *SchedulerError
+	switch {
+	case !errors.Is(err, ErrDeadlock):
+		t.Errorf("Run() error = %v; want %v", err, ErrDeadlock)
+	case !errors.As(err, &serr) || len(serr.Blocked) != 2:
+		t.Errorf("Run() error = %v; want 2 blocked amplifiers", err)
+	default:
+		want := "deadlock: all amplifiers are blocked: " +
+			"amplifier 0 (pc=0) blocked reading its empty input, " +
+			"amplifier 1 (pc=0) blocked reading its empty input"
+		if err.Error() != want {
+			t.Errorf("Run() error = %q; want %q", err, want)
+		}
+	}
+}
+
+func TestSchedulerTimeout(t *testing.T) {
+	// Loop forever.
+	spin := Memory{1105, 1, 0, 0}
+	s := Scheduler{Workers: 2, Timeout: 50 * time.Millisecond}
+	for _, amp := range []*Amplifier{
+		{mem: spin.Copy(), Input: make(chan Intcode, 1), Output: make(chan Intcode, 1)},
+		{mem: Memory{99}, Input: make(chan Intcode, 1), Output: make(chan Intcode, 1)},
+	} {
+		if _, err := s.Spawn(amp); err != nil {
+			t.Fatalf("Spawn() error: %s", err)
+		}
+	}
+	err := s.Run()
+	var serr *SchedulerError
+	switch {
+	case !errors.Is(err, ErrTimeout):
+		t.Errorf("Run() error = %v; want %v", err, ErrTimeout)
+	case !errors.As(err, &serr) || len(serr.Blocked) != 1 || serr.Blocked[0].State() != Running:
+		t.Errorf("Run() error = %v; want 1 running amplifier", err)
+	}
+}
+
+func TestSchedulerTimeoutDeadlock(t *testing.T) {
+	// Loop forever next to two amplifiers reading each other's output.
+	spin := Memory{1105, 1, 0, 0}
+	reader := Memory{3, 3, 99, 0}
+	ab, ba := make(chan Intcode, 1), make(chan Intcode, 1)
+	s := Scheduler{Workers: 2, Timeout: 50 * time.Millisecond}
+	var tasks []*Task
+	for _, amp := range []*Amplifier{
+		{mem: spin.Copy(), Input: make(chan Intcode, 1), Output: make(chan Intcode, 1)},
+		{mem: reader.Copy(), Input: ba, Output: ab},
+		{mem: reader.Copy(), Input: ab, Output: ba},
+	} {
+		task, err := s.Spawn(amp)
+		if err != nil {
+			t.Fatalf("Spawn() error: %s", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := s.Run(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Run() error = %v; want %v", err, ErrTimeout)
+	}
+	for i, want := range []error{ErrTimeout, ErrDeadlock, ErrDeadlock} {
+		if got := tasks[i].Cause(); got != want {
+			t.Errorf("amplifier %d cause = %v; want %v", i, got, want)
+		}
+	}
+}
+
+func TestFeedbackLoopStuck(t *testing.T) {
+	// Read forever without writing.
+	reader := Memory{3, 5, 1105, 1, 0, 0}
+	if _, err := FeedbackLoop(reader, []Intcode{1, 2, 3}); !errors.Is(err, ErrDeadlock) {
+		t.Errorf("FeedbackLoop() error = %v; want %v", err, ErrDeadlock)
+	}
+	// Write forever without reading.
+	writer := Memory{104, 1, 1105, 1, 0, 0}
+	if _, err := FeedbackLoop(writer, []Intcode{1, 2, 3}); !errors.Is(err, ErrDeadlock) {
+		t.Errorf("FeedbackLoop() error = %v; want %v", err, ErrDeadlock)
+	}
+	spin := Memory{1105, 1, 0, 0}
+	if _, err := FeedbackLoopTimeout(spin, []Intcode{1, 2, 3}, 50*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("FeedbackLoopTimeout() error = %v; want %v", err, ErrTimeout)
+	}
+}
+
+func TestSchedulerErrors(t *testing.T) {
+	var s Scheduler
+	for _, mem := range []Memory{{42}, {4, 100}, {99}} {
+		amp := &Amplifier{mem: mem, Input: make(chan Intcode, 1), Output: make(chan Intcode, 1)}
+		if _, err := s.Spawn(amp); err != nil {
+			t.Fatalf("Spawn() error: %s", err)
+		}
+	}
+	err := s.Run()
+	var serr *SchedulerError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Run() error = %v; want a *SchedulerError", err)
+	}
+	if len(serr.Failed) != 2 || len(serr.Blocked) != 0 {
+		t.Errorf("Run() error = %v; want 2 failed amplifiers", err)
+	}
+
+	_, err = s.Spawn(&Amplifier{Input: make(chan Intcode), Output: make(chan Intcode)})
+	if err == nil {
+		t.Errorf("Spawn() with unbuffered channels succeeded")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AmplifierError is the failure of an Amplifier in a feedback loop.
+type AmplifierError struct {
+	Index int   // the Amplifier's position in the feedback loop
+	PC    int64 // the Amplifier's instruction pointer
+	State State // the Amplifier's state, Halted unless it was blocked
+	Err   error
+}
+
+// SequenceResult is the outcome of a feedback loop run with a phase setting
+// sequence.
+type SequenceResult struct {
+	Sequence []Intcode
+	// Signal is the last Amplifier's output, meaningless when the feedback
+	// loop failed.
+	Signal Intcode
+	// Errors are the failures of the Amplifiers, nil when the feedback loop
+	// succeeded.
+	Errors []AmplifierError
+	// Steps is the count of instructions executed by the Amplifiers.
+	Steps int
+}
+
+// RunStats are statistics about a HighestSignal run.
+type RunStats struct {
+	Sequences int           // count of phase setting sequences run
+	Failed    int           // count of sequences with a failed feedback loop
+	Steps     int           // count of instructions executed
+	Elapsed   time.Duration // wall-clock time spent
+}
+
+// SignalResult is the outcome of HighestSignal.
+type SignalResult struct {
+	// Best is the phase setting sequence producing the highest signal.
+	Best SequenceResult
+	// Sequences are every phase setting sequence results, ranked from the
+	// highest signal to the lowest, failed feedback loops last. Sequences
+	// producing the same signal are in lexicographic order.
+	Sequences []SequenceResult
+	Stats     RunStats
+}
+
+// Error implements the error interface for AmplifierError.
+func (e AmplifierError) Error() string {
+	return fmt.Sprintf("amplifier %d (pc=%d %s): %s", e.Index, e.PC, e.State, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e AmplifierError) Unwrap() error {
+	return e.Err
+}
+
+// Failed tells whether the feedback loop failed.
+func (r SequenceResult) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+// String implements Stringer for RunStats.
+func (s RunStats) String() string {
+	return fmt.Sprintf("%d sequences, %d failed, %d instructions in %v",
+		s.Sequences, s.Failed, s.Steps, s.Elapsed)
+}
+
+// HighestSignal run each possible phase setting sequences permutations in
+// a feedback loop of Amplifiers to find signals that can be sent to the
+// thruster. Every feedback loop is run on the same Scheduler, and a failing
+// feedback loop does not prevent the others from running.
+// It return the result of every feedback loop and an error when none of them
+// succeeded.
+func HighestSignal(apc Memory, phases []Intcode) (SignalResult, error) {
+	return HighestSignalTimeout(apc, phases, 0)
+}
+
+// HighestSignalTimeout is like HighestSignal but stops the feedback loops that
+// did not halt within the given timeout, their Amplifiers failing with
+// ErrTimeout, or with ErrDeadlock when blocked by a deadlock, see Task.Cause.
+// A zero timeout means no timeout.
+func HighestSignalTimeout(apc Memory, phases []Intcode, timeout time.Duration) (SignalResult, error) {
+	start := time.Now()
+	s := Scheduler{Timeout: timeout}
+	sequences := Permutations(phases)
+	signals := make([]<-chan Intcode, len(sequences))
+	tasks := make([][]*Task, len(sequences))
+	for i, seq := range sequences {
+		c, ts, err := feedbackLoop(&s, apc, seq)
+		if err != nil {
+			return SignalResult{}, err
+		}
+		signals[i], tasks[i] = c, ts
+	}
+	var serr *SchedulerError
+	if err := s.Run(); err != nil && !errors.As(err, &serr) {
+		return SignalResult{}, err
+	}
+
+	res := SignalResult{Sequences: make([]SequenceResult, len(sequences))}
+	for i, seq := range sequences {
+		r := SequenceResult{Sequence: seq}
+		for j, t := range tasks[i] {
+			r.Steps += t.Steps()
+			switch {
+			case t.Err() != nil:
+				r.Errors = append(r.Errors, AmplifierError{j, t.amp.pc, t.State(), t.Err()})
+			case t.State() != Halted:
+				r.Errors = append(r.Errors, AmplifierError{j, t.amp.pc, t.State(), t.Cause()})
+			}
+		}
+		if !r.Failed() {
+			x, err := lastSignal(signals[i])
+			if err != nil {
+				last := tasks[i][len(seq)-1]
+				r.Errors = append(r.Errors, AmplifierError{len(seq) - 1, last.amp.pc, last.State(), err})
+			}
+			r.Signal = x
+		}
+		res.Sequences[i] = r
+		res.Stats.Steps += r.Steps
+		if r.Failed() {
+			res.Stats.Failed++
+		}
+	}
+	sort.Slice(res.Sequences, func(i, j int) bool {
+		a, b := res.Sequences[i], res.Sequences[j]
+		switch {
+		case a.Failed() != b.Failed():
+			return b.Failed()
+		case !a.Failed() && a.Signal != b.Signal:
+			return a.Signal > b.Signal
+		default:
+			return lexicographicLess(a.Sequence, b.Sequence)
+		}
+	})
+	res.Stats.Sequences = len(sequences)
+	res.Stats.Elapsed = time.Since(start)
+	if len(res.Sequences) == 0 {
+		return res, errors.New("no phase setting sequence")
+	}
+	res.Best = res.Sequences[0]
+	if res.Best.Failed() {
+		return res, fmt.Errorf("every feedback loop failed, e.g. %v: %w", res.Best.Sequence, res.Best.Errors[0])
+	}
+	return res, nil
+}
+
+// lexicographicLess tells whether the sequence a is before b in the
+// lexicographic order.
+func lexicographicLess(a, b []Intcode) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
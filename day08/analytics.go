@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// LayerStats are the statistics of a Layer within its stack.
+type LayerStats struct {
+	// Index is the Layer's position in the stack, 0 being the top.
+	Index int `json:"index"`
+	// Histogram is the count of pixels of each value, see Scheme.Histogram.
+	Histogram []int `json:"histogram"`
+	// Decided is the count of pixels of the final image decided by the Layer.
+	Decided int `json:"decided"`
+	// Contribution is the percentage of the final image's pixels decided by
+	// the Layer.
+	Contribution float64 `json:"contribution"`
+	// Occluded tells whether no pixel of the Layer is visible in the final
+	// image.
+	Occluded bool `json:"occluded"`
+}
+
+// Analysis is the outcome of Scheme.Analyze.
+type Analysis struct {
+	Width  int          `json:"width"`
+	Height int          `json:"height"`
+	Layers []LayerStats `json:"layers"`
+	// Deciders is the index of the layer deciding each pixel of the final
+	// image, row by row, or -1 when the pixel is Clear in every layer.
+	Deciders []int `json:"deciders"`
+}
+
+// Analyze compute the statistics of every layer of the stack according to
+// how they are combined by Flatten. It returns the Analysis and an error if
+// layers is empty or when a Pixel has no Ink.
+func (s Scheme) Analyze(layers []Layer) (Analysis, error) {
+	if len(layers) == 0 {
+		return Analysis{}, errors.New("empty layer stack")
+	}
+	width, height := layers[0].width, layers[0].height
+	a := Analysis{
+		Width:    width,
+		Height:   height,
+		Layers:   make([]LayerStats, len(layers)),
+		Deciders: make([]int, width*height),
+	}
+	for i, l := range layers {
+		h, err := s.Histogram(l)
+		if err != nil {
+			return Analysis{}, fmt.Errorf("layer %d: %w", i, err)
+		}
+		a.Layers[i] = LayerStats{Index: i, Histogram: h}
+	}
+	for i := range a.Deciders {
+		d, err := s.decide(layers, i)
+		if err != nil {
+			return Analysis{}, err
+		}
+		a.Deciders[i] = d
+		if d >= 0 {
+			a.Layers[d].Decided++
+		}
+	}
+	for i := range a.Layers {
+		ls := &a.Layers[i]
+		if n := len(a.Deciders); n > 0 {
+			ls.Contribution = 100 * float64(ls.Decided) / float64(n)
+		}
+		ls.Occluded = ls.Decided == 0
+	}
+	return a, nil
+}
+
+// Occluded returns the index of every layer having no pixel visible in the
+// final image.
+func (a Analysis) Occluded() []int {
+	var occluded []int
+	for _, ls := range a.Layers {
+		if ls.Occluded {
+			occluded = append(occluded, ls.Index)
+		}
+	}
+	return occluded
+}
+
+// WriteJSON write the Analysis as JSON into w. It returns any encoding or
+// write error encountered.
+func (a Analysis) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// WriteTable write the Analysis into w as a table of layers followed by the
+// deciding layer of each pixel. It returns any write error encountered.
+func (a Analysis) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "layer\t")
+	if len(a.Layers) > 0 {
+		for v := range a.Layers[0].Histogram {
+			fmt.Fprintf(tw, "%d\t", v)
+		}
+	}
+	fmt.Fprint(tw, "decided\tcontribution\toccluded\t\n")
+	for _, ls := range a.Layers {
+		fmt.Fprintf(tw, "%d\t", ls.Index)
+		for _, n := range ls.Histogram {
+			fmt.Fprintf(tw, "%d\t", n)
+		}
+		fmt.Fprintf(tw, "%d\t%.2f%%\t%t\t\n", ls.Decided, ls.Contribution, ls.Occluded)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// The deciders are drawn as a grid, the widest layer index defining the
+	// columns width.
+	size := len(strconv.Itoa(len(a.Layers) - 1))
+	var buf strings.Builder
+	buf.WriteString("\ndeciding layers:\n")
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			if x > 0 {
+				buf.WriteByte(' ')
+			}
+			if d := a.Deciders[y*a.Width+x]; d < 0 {
+				fmt.Fprintf(&buf, "%*s", size, "-")
+			} else {
+				fmt.Fprintf(&buf, "%*d", size, d)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	layers, err := Parse(2, 2, strings.NewReader("02221122221200001111"))
+	if err != nil {
+		t.Fatalf("Parse() error: %s", err)
+	}
+	a, err := DefaultScheme.Analyze(layers)
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+	if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(a.Deciders, want) {
+		t.Errorf("Deciders = %v; want %v", a.Deciders, want)
+	}
+	want := []LayerStats{
+		{Index: 0, Histogram: []int{1, 0, 3}, Decided: 1, Contribution: 25},
+		{Index: 1, Histogram: []int{0, 2, 2}, Decided: 1, Contribution: 25},
+		{Index: 2, Histogram: []int{0, 1, 3}, Decided: 1, Contribution: 25},
+		{Index: 3, Histogram: []int{4, 0, 0}, Decided: 1, Contribution: 25},
+		{Index: 4, Histogram: []int{0, 4, 0}, Occluded: true},
+	}
+	if !reflect.DeepEqual(a.Layers, want) {
+		t.Errorf("Layers = %+v; want %+v", a.Layers, want)
+	}
+	if got := a.Occluded(); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("Occluded() = %v; want [4]", got)
+	}
+
+	// A pixel Clear in every layer is decided by none.
+	a, err = DefaultScheme.Analyze([]Layer{{1, 1, []Pixel{Trans}}})
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+	if a.Deciders[0] != -1 || !a.Layers[0].Occluded {
+		t.Errorf("Analyze() = %+v; want an undecided pixel", a)
+	}
+
+	if _, err := DefaultScheme.Analyze(nil); err == nil {
+		t.Error("Analyze(nil) succeeded; want an error")
+	}
+}
+
+func TestAnalysisOutput(t *testing.T) {
+	layers := []Layer{
+		{2, 1, []Pixel{Trans, White}},
+		{2, 1, []Pixel{Black, Black}},
+	}
+	a, err := DefaultScheme.Analyze(layers)
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error: %s", err)
+	}
+	var decoded Analysis
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, a) {
+		t.Errorf("WriteJSON() decoded = %+v; want %+v", decoded, a)
+	}
+
+	buf.Reset()
+	if err := a.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable() error: %s", err)
+	}
+	want := "" +
+		"  layer  0  1  2  decided  contribution  occluded\n" +
+		"      0  0  1  1        1        50.00%     false\n" +
+		"      1  2  0  0        1        50.00%     false\n" +
+		"\n" +
+		"deciding layers:\n" +
+		"1 0\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTable() = %q; want %q", got, want)
+	}
+}
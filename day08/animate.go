@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Frame palette indexes. The first MaxInks indexes are the colors of the
+// Pixel values.
+const (
+	frameBlack = Black
+	frameWhite = White
+	frameLight = MaxInks     // light checkerboard square
+	frameDark  = MaxInks + 1 // dark checkerboard square
+	frameGap   = MaxInks + 2 // space between side by side views
+)
+
+// framePalette returns the palette of every animation frame drawn with the
+// given Scheme.
+func framePalette(s Scheme) color.Palette {
+	p := make(color.Palette, MaxInks, MaxInks+3)
+	for i := range p {
+		p[i] = color.Transparent // unused
+	}
+	copy(p, s.Palette())
+	return append(p,
+		color.Gray{Y: 0xcc},          // frameLight
+		color.Gray{Y: 0x99},          // frameDark
+		color.RGBA{R: 0x80, A: 0xff}, // frameGap
+	)
+}
+
+// FrameOptions control how the layer stack is rendered by Frames.
+type FrameOptions struct {
+	// Scale is the size of the square drawn for each pixel, at least one.
+	Scale int
+	// Raw tells whether the frames show the layer added to the stack on the
+	// right side of the flattened image.
+	Raw bool
+	// Delay is the time between GIF frames in hundredths of a second.
+	Delay int
+	// Scheme is used to flatten and draw the layers. When nil, the
+	// DefaultScheme is used.
+	Scheme Scheme
+}
+
+// Frames render the composition of the layer stack. The k-th frame is the
+// Flatten of the first k layers, except that the pixels Clear in all of them
+// stay Clear and are drawn as a checkerboard.
+// It returns the frames and an error when the stack is empty or the options
+// are invalid.
+func Frames(layers []Layer, opts FrameOptions) ([]*image.Paletted, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("empty layer stack")
+	}
+	if opts.Scale < 1 {
+		return nil, fmt.Errorf("invalid scale %d", opts.Scale)
+	}
+	s := opts.Scheme
+	if s == nil {
+		s = DefaultScheme
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	palette := framePalette(s)
+	w, h := layers[0].width*opts.Scale, layers[0].height*opts.Scale
+	gap := opts.Scale // width of the gap between the side by side views
+	bounds := image.Rect(0, 0, w, h)
+	if opts.Raw {
+		bounds.Max.X = 2*w + gap
+	}
+	frames := make([]*image.Paletted, len(layers))
+	for k := range layers {
+		flat, err := s.compose(layers[:k+1], true)
+		if err != nil {
+			return nil, err
+		}
+		img := image.NewPaletted(bounds, palette)
+		draw(img, s, flat, 0, opts.Scale)
+		if opts.Raw {
+			for y := 0; y < h; y++ {
+				for x := w; x < w+gap; x++ {
+					img.SetColorIndex(x, y, frameGap)
+				}
+			}
+			draw(img, s, layers[k], w+gap, opts.Scale)
+		}
+		frames[k] = img
+	}
+	return frames, nil
+}
+
+// draw the Layer l on img at the horizontal offset x0, each pixel being a
+// scale by scale square. Clear pixels are drawn as a checkerboard whose
+// squares are half a pixel wide.
+func draw(img *image.Paletted, s Scheme, l Layer, x0, scale int) {
+	checker := scale / 2
+	if checker == 0 {
+		checker = 1
+	}
+	for y := 0; y < l.height*scale; y++ {
+		for x := 0; x < l.width*scale; x++ {
+			idx := uint8(l.pixels[(y/scale)*l.width+x/scale])
+			if int(idx) >= len(s) || s[idx].Blend == Clear {
+				if (x/checker+y/checker)%2 == 0 {
+					idx = frameLight
+				} else {
+					idx = frameDark
+				}
+			}
+			img.SetColorIndex(x0+x, y, idx)
+		}
+	}
+}
+
+// WriteGIF write the layer stack composition, see Frames, as an animated GIF
+// into w. It returns any rendering, encoding or write error encountered.
+func WriteGIF(w io.Writer, layers []Layer, opts FrameOptions) error {
+	frames, err := Frames(layers, opts)
+	if err != nil {
+		return err
+	}
+	anim := gif.GIF{Image: frames, Delay: make([]int, len(frames))}
+	for i := range anim.Delay {
+		anim.Delay[i] = opts.Delay
+	}
+	return gif.EncodeAll(w, &anim)
+}
+
+// WriteFrames write the layer stack composition, see Frames, as a sequence of
+// PNG images named frame-0001.png, frame-0002.png etc. into the directory dir.
+// It returns any rendering, encoding or write error encountered.
+func WriteFrames(dir string, layers []Layer, opts FrameOptions) error {
+	frames, err := Frames(layers, opts)
+	if err != nil {
+		return err
+	}
+	for i, img := range frames {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i+1)))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
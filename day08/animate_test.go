@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	layers := []Layer{
+		{2, 1, []Pixel{Trans, White}},
+		{2, 1, []Pixel{Black, Black}},
+	}
+	// the expected colors of the frames.
+	var (
+		b = color.Black
+		w = color.White
+		l = color.Gray{Y: 0xcc}          // light checkerboard square
+		d = color.Gray{Y: 0x99}          // dark checkerboard square
+		g = color.RGBA{R: 0x80, A: 0xff} // gap
+	)
+	tests := []struct {
+		name   string
+		raw    bool
+		width  int
+		frames [][]color.Color // first row of each frame
+	}{
+		{"flat", false, 4, [][]color.Color{
+			{l, d, w, w},
+			{b, b, w, w},
+		}},
+		{"raw", true, 10, [][]color.Color{
+			{l, d, w, w, g, g, l, d, w, w},
+			{b, b, w, w, g, g, b, b, b, b},
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			frames, err := Frames(layers, FrameOptions{Scale: 2, Raw: tc.raw})
+			if err != nil {
+				t.Fatalf("Frames() error: %s", err)
+			}
+			if len(frames) != len(tc.frames) {
+				t.Fatalf("got %d frames; want %d", len(frames), len(tc.frames))
+			}
+			for i, img := range frames {
+				if b := img.Bounds(); b.Dx() != tc.width || b.Dy() != 2 {
+					t.Fatalf("frame %d size = %dx%d; want %dx2", i, b.Dx(), b.Dy(), tc.width)
+				}
+				for x, want := range tc.frames[i] {
+					if got := img.At(x, 0); !sameColor(got, want) {
+						t.Errorf("frame %d color at (%d, 0) = %v; want %v", i, x, got, want)
+					}
+				}
+			}
+		})
+	}
+	if _, err := Frames(nil, FrameOptions{Scale: 1}); err == nil {
+		t.Error("Frames(nil) succeeded; want an error")
+	}
+	if _, err := Frames(layers, FrameOptions{}); err == nil {
+		t.Error("Frames() with a zero scale succeeded; want an error")
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	layers := []Layer{
+		{2, 2, []Pixel{Trans, White, Trans, Black}},
+		{2, 2, []Pixel{White, Trans, Trans, Trans}},
+		{2, 2, []Pixel{Black, Black, Black, Black}},
+	}
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, layers, FrameOptions{Scale: 1, Delay: 5}); err != nil {
+		t.Fatalf("WriteGIF() error: %s", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error: %s", err)
+	}
+	if len(anim.Image) != len(layers) {
+		t.Fatalf("got %d frames; want %d", len(anim.Image), len(layers))
+	}
+	for i, d := range anim.Delay {
+		if d != 5 {
+			t.Errorf("frame %d delay = %d; want 5", i, d)
+		}
+	}
+	// the expected colors of the first and last frames, row by row.
+	want := map[int][]color.Color{
+		0: {color.Gray{Y: 0xcc}, color.White, color.Gray{Y: 0x99}, color.Black},
+		2: {color.White, color.White, color.Black, color.Black},
+	}
+	for i, colors := range want {
+		for j, c := range colors {
+			x, y := j%2, j/2
+			if got := anim.Image[i].At(x, y); !sameColor(got, c) {
+				t.Errorf("frame %d color at (%d, %d) = %v; want %v", i, x, y, got, c)
+			}
+		}
+	}
+}
+
+// sameColor reports whether a and b are the same color once converted to
+// alpha-premultiplied RGBA.
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+func TestWriteFrames(t *testing.T) {
+	dir := t.TempDir()
+	layers := []Layer{
+		{1, 1, []Pixel{Trans}},
+		{1, 1, []Pixel{White}},
+	}
+	if err := WriteFrames(dir, layers, FrameOptions{Scale: 1}); err != nil {
+		t.Fatalf("WriteFrames() error: %s", err)
+	}
+	for _, name := range []string{"frame-0001.png", "frame-0002.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing frame: %s", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
+)
+
+// Dimensions are candidate layer dimensions for a Space Image Format.
+type Dimensions struct {
+	Width, Height int
+	// Score is the legibility of the flattened image, see Legibility.
+	Score float64
+}
+
+// Detect infer the plausible layer dimensions of the given pixels using the
+// DefaultScheme, see Scheme.Detect.
+func Detect(pixels []Pixel) ([]Dimensions, error) {
+	return DefaultScheme.Detect(pixels)
+}
+
+// Detect infer the plausible layer dimensions of the given pixels, i.e. every
+// width and height pair dividing the pixels count, at least two pixels each.
+// It returns the candidates ranked from the most legible flattened image to
+// the least, and an error when there is none or when a Pixel has no Ink.
+func (s Scheme) Detect(pixels []Pixel) ([]Dimensions, error) {
+	var candidates []Dimensions
+	n := len(pixels)
+	for lpc := 4; lpc <= n; lpc++ {
+		if n%lpc != 0 {
+			continue
+		}
+		for height := 2; height <= lpc/2; height++ {
+			if lpc%height != 0 {
+				continue
+			}
+			width := lpc / height
+			layers, err := Layers(width, height, pixels)
+			if err != nil {
+				return nil, err
+			}
+			flat, err := s.Flatten(layers)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, Dimensions{width, height, s.Legibility(flat)})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no plausible dimensions")
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// Legibility returns the legibility score of the given image drawn with the
+// DefaultScheme, see Scheme.Legibility.
+func Legibility(l Layer) float64 {
+	return DefaultScheme.Legibility(l)
+}
+
+// Legibility returns a heuristic score of how legible the given image is,
+// between zero and two. The first half is the proportion of neighbour pixels
+// having the same color, as scrambled images are noisy. The second half is
+// the proportion of glyphs recognized as letters, see Scheme.Bitmap.
+func (s Scheme) Legibility(l Layer) float64 {
+	same, pairs := 0, 0
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			p := l.pixels[y*l.width+x]
+			if x+1 < l.width {
+				pairs++
+				if l.pixels[y*l.width+x+1] == p {
+					same++
+				}
+			}
+			if y+1 < l.height {
+				pairs++
+				if l.pixels[(y+1)*l.width+x] == p {
+					same++
+				}
+			}
+		}
+	}
+	score := float64(same) / float64(pairs)
+	text, _ := ocr.Recognize(s.Bitmap(l))
+	if glyphs := []rune(text); len(glyphs) > 0 {
+		known := 0
+		for _, r := range glyphs {
+			if r != ocr.Unknown {
+				known++
+			}
+		}
+		score += float64(known) / float64(len(glyphs))
+	}
+	return score
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{"leftover digits", "0222112222120000012", 16},
+		{"leftover digits after newlines", "0222\n11222212\r\n0000012\n", 19},
+		{"invalid byte", "0222\n11x2", 7},
+		{"invalid value", "0222112252120000", 8},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(2, 2, strings.NewReader(tc.input))
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Parse() error = %v; want a *SyntaxError", err)
+			}
+			if serr.Offset != tc.offset {
+				t.Errorf("Parse() error offset = %d; want %d", serr.Offset, tc.offset)
+			}
+		})
+	}
+	if _, err := Parse(0, 2, strings.NewReader(encoded)); err == nil {
+		t.Errorf("Parse() with a zero width error = nil; want an error")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	// HI on 9x6 pixels, hidden in 8 layers.
+	img := Layer{9, 6, []Pixel{
+		1, 0, 0, 1, 0, 1, 1, 1, 0,
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		1, 1, 1, 1, 0, 0, 1, 0, 0,
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		1, 0, 0, 1, 0, 1, 1, 1, 0,
+	}}
+	layers, err := Encode(img, EncodeOptions{Layers: 8, Randomize: true, Obfuscate: true})
+	if err != nil {
+		t.Fatalf("Encode() error: %s", err)
+	}
+	var pixels []Pixel
+	for _, l := range layers {
+		pixels = append(pixels, l.pixels...)
+	}
+	candidates, err := Detect(pixels)
+	if err != nil {
+		t.Fatalf("Detect() error: %s", err)
+	}
+	if best := candidates[0]; best.Width != 9 || best.Height != 6 {
+		t.Errorf("Detect() best = %dx%d; want 9x6", best.Width, best.Height)
+	}
+}
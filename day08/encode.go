@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png" // register the PNG format for image.Decode
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+)
+
+// EncodeOptions control how Encode hide an image in a layer stack.
+type EncodeOptions struct {
+	// Layers is the count of layers to generate, at least one.
+	Layers int
+	// Randomize fill the pixels hidden below the visible ones with random
+	// digits instead of Trans.
+	Randomize bool
+	// Obfuscate spread the visible pixels across all the layers instead of
+	// drawing them all in the first layer, the pixels above them being
+	// Trans.
+	Obfuscate bool
+	// Rand is the source of randomness for Randomize and Obfuscate. When nil,
+	// a source seeded with 1 is used so that the output is deterministic.
+	Rand *rand.Rand
+}
+
+// Encode hide the given image in a Space Image Format layer stack, i.e.
+// Flatten on the returned layers reproduces the image. Trans pixels of the
+// image are Trans in every layer, so that they are left to whatever lies
+// below the stack, Flatten drawing them Black. It returns the layers and an error when the options are
+// invalid or when the image is not only made of Black, White and Trans
+// pixels.
+func Encode(img Layer, opts EncodeOptions) ([]Layer, error) {
+	n := opts.Layers
+	if n < 1 {
+		return nil, fmt.Errorf("invalid layer count %d", n)
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+	for i, p := range img.pixels {
+		if p != Black && p != White && p != Trans {
+			return nil, fmt.Errorf("pixel %d at (%d, %d) is neither Black, White nor Trans", p, i%img.width, i/img.width)
+		}
+	}
+	layers := make([]Layer, n)
+	for i := range layers {
+		l, err := NewLayer(img.width, img.height, make([]Pixel, len(img.pixels)))
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = l
+	}
+	for i, p := range img.pixels {
+		visible := 0 // the layer where p is drawn
+		if opts.Obfuscate {
+			visible = rng.Intn(n)
+		}
+		for j, l := range layers {
+			switch {
+			case p == Trans:
+				l.pixels[i] = Trans
+			case j < visible:
+				l.pixels[i] = Trans
+			case j == visible:
+				l.pixels[i] = p
+			case opts.Randomize:
+				l.pixels[i] = Pixel(rng.Intn(3))
+			default:
+				l.pixels[i] = Trans
+			}
+		}
+	}
+	return layers, nil
+}
+
+// WriteSIF write the given layers as a Space Image Format digit stream into
+// w, followed by a newline like the puzzle input. It returns any write error
+// encountered.
+func WriteSIF(w io.Writer, layers []Layer) error {
+	bw := bufio.NewWriter(w)
+	for _, l := range layers {
+		for _, p := range l.pixels {
+			if p > 9 {
+				return fmt.Errorf("pixel %d is not a digit", p)
+			}
+			bw.WriteByte('0' + byte(p))
+		}
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// ReadImage read the image in the file at path, either a PNG or a Netpbm
+// bitmap (PBM), as a Layer. Transparent pixels are Trans, the others are
+// Black when dark and White when bright. It returns the Layer and any read or
+// decoding error encountered.
+func ReadImage(path string) (Layer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Layer{}, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 'P' && (magic[1] == '1' || magic[1] == '4') {
+		return readPBM(r)
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return Layer{}, err
+	}
+	b := img.Bounds()
+	pixels := make([]Pixel, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.At(x, y)
+			_, _, _, a := c.RGBA()
+			switch {
+			case a < 0x8000:
+				pixels = append(pixels, Trans)
+			case color.GrayModel.Convert(c).(color.Gray).Y < 0x80:
+				pixels = append(pixels, Black)
+			default:
+				pixels = append(pixels, White)
+			}
+		}
+	}
+	return NewLayer(b.Dx(), b.Dy(), pixels)
+}
+
+// readPBM decode a plain (P1) or binary (P4) Netpbm bitmap from r, where a 1
+// is a Black Pixel.
+func readPBM(r *bufio.Reader) (Layer, error) {
+	// token returns the next whitespace separated header token, skipping
+	// comments.
+	token := func() (string, error) {
+		var tok bytes.Buffer
+		for {
+			b, err := r.ReadByte()
+			if err == io.EOF && tok.Len() > 0 {
+				return tok.String(), nil
+			} else if err != nil {
+				return "", err
+			}
+			switch b {
+			case '#':
+				if _, err := r.ReadString('\n'); err != nil {
+					return "", err
+				}
+			case ' ', '\t', '\r', '\n':
+				if tok.Len() > 0 {
+					return tok.String(), nil
+				}
+			default:
+				tok.WriteByte(b)
+			}
+		}
+	}
+	magic, err := token()
+	if err != nil {
+		return Layer{}, err
+	}
+	var dims [2]int
+	for i := range dims {
+		s, err := token()
+		if err != nil {
+			return Layer{}, err
+		}
+		if dims[i], err = strconv.Atoi(s); err != nil || dims[i] < 0 {
+			return Layer{}, fmt.Errorf("invalid PBM dimension %q", s)
+		}
+	}
+	width, height := dims[0], dims[1]
+	pixels := make([]Pixel, 0, width*height)
+	bit := func(black bool) {
+		if black {
+			pixels = append(pixels, Black)
+		} else {
+			pixels = append(pixels, White)
+		}
+	}
+	switch magic {
+	case "P1":
+		for len(pixels) < width*height {
+			b, err := r.ReadByte()
+			if err != nil {
+				return Layer{}, fmt.Errorf("PBM pixel %d: %w", len(pixels), err)
+			}
+			switch b {
+			case '0', '1':
+				bit(b == '1')
+			case '#':
+				if _, err := r.ReadString('\n'); err != nil {
+					return Layer{}, err
+				}
+			}
+		}
+	case "P4":
+		row := make([]byte, (width+7)/8)
+		for y := 0; y < height; y++ {
+			if _, err := io.ReadFull(r, row); err != nil {
+				return Layer{}, fmt.Errorf("PBM row %d: %w", y, err)
+			}
+			for x := 0; x < width; x++ {
+				bit(row[x/8]&(0x80>>(x%8)) != 0)
+			}
+		}
+	default:
+		return Layer{}, errors.New("not a PBM image")
+	}
+	return NewLayer(width, height, pixels)
+}
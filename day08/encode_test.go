@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	img := Layer{3, 2, []Pixel{0, 1, 2, 0, 0, 1}}
+	tests := []struct {
+		name string
+		opts EncodeOptions
+	}{
+		{"single layer", EncodeOptions{Layers: 1}},
+		{"transparent hidden layers", EncodeOptions{Layers: 5}},
+		{"randomized", EncodeOptions{Layers: 5, Randomize: true}},
+		{"obfuscated", EncodeOptions{Layers: 20, Obfuscate: true, Rand: rand.New(rand.NewSource(42))}},
+		{"randomized and obfuscated", EncodeOptions{Layers: 20, Randomize: true, Obfuscate: true}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			layers, err := Encode(img, tc.opts)
+			if err != nil {
+				t.Fatalf("Encode() error: %s", err)
+			}
+			// round-trip through the digit stream.
+			var buf bytes.Buffer
+			if err := WriteSIF(&buf, layers); err != nil {
+				t.Fatalf("WriteSIF() error: %s", err)
+			}
+			decoded, err := Parse(img.width, img.height, &buf)
+			if err != nil {
+				t.Fatalf("Parse() error: %s", err)
+			}
+			if len(decoded) != tc.opts.Layers {
+				t.Fatalf("got %d layers; want %d", len(decoded), tc.opts.Layers)
+			}
+			// keep the transparency to check that the Trans pixel is Trans
+			// in every layer.
+			flat, err := DefaultScheme.compose(decoded, true)
+			if err != nil {
+				t.Fatalf("compose() error: %s", err)
+			}
+			if !LayerEquals(flat, img) {
+				t.Errorf("compose() = %v; want %v", flat, img)
+			}
+			if tc.opts.Obfuscate && LayerEquals(decoded[0], img) {
+				t.Errorf("first layer = %v; want the image to be obfuscated", decoded[0])
+			}
+		})
+	}
+
+	if _, err := Encode(Layer{1, 1, []Pixel{3}}, EncodeOptions{Layers: 1}); err == nil {
+		t.Errorf("Encode() of a pixel without ink error = nil; want an error")
+	}
+	if _, err := Encode(img, EncodeOptions{}); err == nil {
+		t.Errorf("Encode() without layers error = nil; want an error")
+	}
+}
+
+func TestReadImage(t *testing.T) {
+	img := Layer{9, 2, []Pixel{
+		1, 0, 1, 0, 1, 0, 1, 0, 1,
+		0, 0, 0, 0, 1, 1, 1, 1, 0,
+	}}
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.pbm")
+	pbm := "P1\n# a comment\n9 2\n0 1 0 1 0 1 0 1 0\n111100001\n"
+	if err := os.WriteFile(plain, []byte(pbm), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{plain, filepath.Join(dir, "binary.pbm"), filepath.Join(dir, "image.png")} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if path != plain {
+				if err := WriteImage(path, img, 1, DefaultPalette); err != nil {
+					t.Fatalf("WriteImage() error: %s", err)
+				}
+			}
+			got, err := ReadImage(path)
+			if err != nil {
+				t.Fatalf("ReadImage() error: %s", err)
+			}
+			if !LayerEquals(got, img) {
+				t.Errorf("ReadImage() = %v; want %v", got, img)
+			}
+		})
+	}
+}
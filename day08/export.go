@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultPalette map each Pixel value to its color, i.e. Black to black,
+// White to white and Trans to transparent.
+var DefaultPalette = color.Palette{
+	Black: color.Black,
+	White: color.White,
+	Trans: color.Transparent,
+}
+
+// Image returns the Layer as an image where each Pixel is a scale by scale
+// square of the Pixel's color in the palette p. It returns an error when
+// scale is not positive or when a Pixel has no color in p.
+func (l Layer) Image(scale int, p color.Palette) (*image.Paletted, error) {
+	if scale < 1 {
+		return nil, fmt.Errorf("invalid scale %d", scale)
+	}
+	img := image.NewPaletted(image.Rect(0, 0, l.width*scale, l.height*scale), p)
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			px := l.pixels[y*l.width+x]
+			if int(px) >= len(p) {
+				return nil, fmt.Errorf("pixel %d at (%d, %d) has no color in the palette", px, x, y)
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(x*scale+dx, y*scale+dy, uint8(px))
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// EncodePNG write the Layer as a PNG image into w, see Image. Transparent
+// colors of the palette, like Trans in DefaultPalette, are written in the
+// PNG's alpha channel. It returns any encoding or write error encountered.
+func (l Layer) EncodePNG(w io.Writer, scale int, p color.Palette) error {
+	img, err := l.Image(scale, p)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// EncodePGM write the Layer as a binary Netpbm grayscale image (PGM) into w,
+// see Image. Since PGM has no alpha channel, transparent colors are blended
+// over a white background. It returns any encoding or write error
+// encountered.
+func (l Layer) EncodePGM(w io.Writer, scale int, p color.Palette) error {
+	img, err := l.Image(scale, p)
+	if err != nil {
+		return err
+	}
+	grays := make([]uint8, len(p))
+	for i, c := range p {
+		grays[i] = gray(c)
+	}
+	b := img.Bounds()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P5\n%d %d\n255\n", b.Dx(), b.Dy())
+	for _, i := range img.Pix {
+		bw.WriteByte(grays[i])
+	}
+	return bw.Flush()
+}
+
+// EncodePBM write the Layer as a binary Netpbm bitmap image (PBM) into w, see
+// Image. Pixels whose color is darker than mid-gray once blended over a
+// white background are black, the others are white. It returns any encoding
+// or write error encountered.
+func (l Layer) EncodePBM(w io.Writer, scale int, p color.Palette) error {
+	img, err := l.Image(scale, p)
+	if err != nil {
+		return err
+	}
+	black := make([]bool, len(p))
+	for i, c := range p {
+		black[i] = gray(c) < 0x80
+	}
+	b := img.Bounds()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P4\n%d %d\n", b.Dx(), b.Dy())
+	// Each row is packed into bytes, most significant bit first, with 1 for
+	// black.
+	for y := 0; y < b.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+b.Dx()]
+		var packed byte
+		for x, i := range row {
+			if black[i] {
+				packed |= 0x80 >> (x % 8)
+			}
+			if x%8 == 7 || x == len(row)-1 {
+				bw.WriteByte(packed)
+				packed = 0
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// Encode write the Layer into w in the format named by ext, one of ".png",
+// ".pgm" or ".pbm". It returns any encoding or write error encountered.
+func (l Layer) Encode(w io.Writer, ext string, scale int, p color.Palette) error {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return l.EncodePNG(w, scale, p)
+	case ".pgm":
+		return l.EncodePGM(w, scale, p)
+	case ".pbm":
+		return l.EncodePBM(w, scale, p)
+	default:
+		return fmt.Errorf("unsupported image format %q", ext)
+	}
+}
+
+// WriteImage write the Layer into the file at path, its format being guessed
+// from the file extension, see Encode. It returns any encoding or write error
+// encountered.
+func WriteImage(path string, l Layer, scale int, p color.Palette) error {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return errors.New("missing image file extension")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := l.Encode(f, ext, scale, p); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ParsePalette parse a comma separated list of colors, the first one being
+// the color of the Pixel value 0, the second one of the Pixel value 1 etc.
+// Each color is either in the RRGGBB or RRGGBBAA hexadecimal notation, with
+// an optional leading #. It returns the palette and any parsing error
+// encountered.
+func ParsePalette(s string) (color.Palette, error) {
+	var p color.Palette
+	for _, hex := range strings.Split(s, ",") {
+		hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		rgba, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return nil, fmt.Errorf("invalid color %q", hex)
+		}
+		p = append(p, color.NRGBA{
+			R: uint8(rgba >> 24),
+			G: uint8(rgba >> 16),
+			B: uint8(rgba >> 8),
+			A: uint8(rgba),
+		})
+	}
+	return p, nil
+}
+
+// gray returns the luminance of c blended over a white background.
+func gray(c color.Color) uint8 {
+	r, g, b, a := c.RGBA() // alpha-premultiplied
+	over := func(x uint32) uint32 { return x + 0xffff - a }
+	y := color.GrayModel.Convert(color.RGBA64{
+		R: uint16(over(r)),
+		G: uint16(over(g)),
+		B: uint16(over(b)),
+		A: 0xffff,
+	})
+	return y.(color.Gray).Y
+}
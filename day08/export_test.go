@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestEncodePNG(t *testing.T) {
+	l := Layer{3, 1, []Pixel{Black, White, Trans}}
+	var buf bytes.Buffer
+	if err := l.EncodePNG(&buf, 2, DefaultPalette); err != nil {
+		t.Fatalf("EncodePNG() error: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error: %s", err)
+	}
+	if b := img.Bounds(); b.Dx() != 6 || b.Dy() != 2 {
+		t.Fatalf("image size = %dx%d; want 6x2", b.Dx(), b.Dy())
+	}
+	for x, want := range []uint32{0xffff, 0xffff, 0xffff, 0xffff, 0, 0} {
+		if _, _, _, a := img.At(x, 1).RGBA(); a != want {
+			t.Errorf("alpha at (%d, 1) = %#x; want %#x", x, a, want)
+		}
+	}
+	if r, _, _, _ := img.At(2, 0).RGBA(); r != 0xffff {
+		t.Errorf("red at (2, 0) = %#x; want %#x", r, 0xffff)
+	}
+}
+
+func TestEncodeNetpbm(t *testing.T) {
+	l := Layer{9, 2, []Pixel{
+		1, 0, 1, 0, 1, 0, 1, 0, 1,
+		2, 2, 2, 2, 0, 0, 0, 0, 1,
+	}}
+	var buf bytes.Buffer
+	if err := l.EncodePBM(&buf, 1, DefaultPalette); err != nil {
+		t.Fatalf("EncodePBM() error: %s", err)
+	}
+	want := "P4\n9 2\n\x55\x00\x0f\x00"
+	if got := buf.String(); got != want {
+		t.Errorf("EncodePBM() = %q; want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := l.EncodePGM(&buf, 1, DefaultPalette); err != nil {
+		t.Fatalf("EncodePGM() error: %s", err)
+	}
+	want = "P5\n9 2\n255\n" +
+		"\xff\x00\xff\x00\xff\x00\xff\x00\xff" +
+		"\xff\xff\xff\xff\x00\x00\x00\x00\xff"
+	if got := buf.String(); got != want {
+		t.Errorf("EncodePGM() = %q; want %q", got, want)
+	}
+}
+
+func TestParsePalette(t *testing.T) {
+	p, err := ParsePalette("#000000, ff8000,12345678")
+	if err != nil {
+		t.Fatalf("ParsePalette() error: %s", err)
+	}
+	want := color.Palette{
+		color.NRGBA{0, 0, 0, 0xff},
+		color.NRGBA{0xff, 0x80, 0, 0xff},
+		color.NRGBA{0x12, 0x34, 0x56, 0x78},
+	}
+	if len(p) != len(want) {
+		t.Fatalf("ParsePalette() = %v; want %v", p, want)
+	}
+	for i := range want {
+		if p[i] != want[i] {
+			t.Errorf("ParsePalette()[%d] = %v; want %v", i, p[i], want[i])
+		}
+	}
+	if _, err := ParsePalette("fff"); err == nil {
+		t.Errorf("ParsePalette(fff) error = nil; want an error")
+	}
+}
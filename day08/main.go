@@ -1,14 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"os"
-	"strconv"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
 )
 
 const (
@@ -42,33 +44,10 @@ func NewLayer(width int, height int, pixels []Pixel) (Layer, error) {
 }
 
 // Flatten take a stack of layer and combine them from the top (first) to the
-// bottom (last). It return the combined Layer and an error if layers is empty.
+// bottom (last) using the DefaultScheme. Pixels transparent in every layer are
+// Black. It return the combined Layer and an error if layers is empty.
 func Flatten(layers []Layer) (Layer, error) {
-	var flat Layer
-	if len(layers) == 0 {
-		return flat, errors.New("empty layer stack")
-	}
-	// Create the flat image layer using the first layer's dimensions.
-	width, height := layers[0].width, layers[0].height
-	flat, err := NewLayer(width, height, make([]Pixel, width*height))
-	if err != nil {
-		return flat, err
-	}
-	// Black being the zero-value for Pixel, we start with an all-black Layer
-	// here. Since we never check for Pixel values in flat it is not an issue.
-	for i := range flat.pixels {
-	layersLoop:
-		for _, l := range layers {
-			// XXX: we assume here that every layer in layers has the same
-			// dimensions as the first one.
-			switch p := l.pixels[i]; p {
-			case Black, White:
-				flat.pixels[i] = p
-				break layersLoop
-			}
-		}
-	}
-	return flat, nil
+	return DefaultScheme.Flatten(layers)
 }
 
 // MinLayerBy returns the first Layer l that evaluate to the minimum value
@@ -113,6 +92,8 @@ func (l Layer) String() string {
 				buf.WriteString("⬜") // 'WHITE LARGE SQUARE' (U+2B1C)
 			case Trans:
 				buf.WriteString("  ")
+			case 3, 4, 5, 6, 7, 8, 9:
+				buf.WriteRune('０' + rune(p)) // 'FULLWIDTH DIGIT ZERO' (U+FF10)
 			default:
 				buf.WriteString("??")
 			}
@@ -122,12 +103,64 @@ func (l Layer) String() string {
 	return buf.String()
 }
 
+// Bitmap returns the Layer as a monochrome image where White pixels are lit.
+func (l Layer) Bitmap() ocr.Bitmap {
+	return DefaultScheme.Bitmap(l)
+}
+
 // main parse the puzzle provided on stdin and then compute the number of 1
 // digits multiplied by the number of 2 digits from the layer having the fewest
-// 0 digits.
+// 0 digits. When the first argument is "stats", the statistics of every layer
+// are displayed instead.
 func main() {
-	width, height := 25, 6
-	layers, err := Parse(width, height, os.Stdin)
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		stats(os.Args[2:])
+		return
+	}
+	output := flag.String("o", "", "write the decoded message into `file`, a .png, .pgm or .pbm image")
+	scale := flag.Int("scale", 1, "the image size of a pixel, in `n` by n squares")
+	palette := flag.String("palette", "", "the `inks` of each pixel value as comma separated RRGGBB[AA][:opaque|clear|under]")
+	encode := flag.String("encode", "", "write the Space Image Format of the .png or .pbm image `file` on stdout instead of decoding")
+	var opts EncodeOptions
+	flag.IntVar(&opts.Layers, "layers", 100, "the count of layers to encode")
+	flag.BoolVar(&opts.Randomize, "randomize", false, "fill the hidden pixels with random digits when encoding")
+	flag.BoolVar(&opts.Obfuscate, "obfuscate", false, "spread the visible pixels across the layers when encoding")
+	seed := flag.Int64("seed", 1, "the random `seed` used when encoding")
+	width := flag.Int("width", 25, "the image width in pixels")
+	height := flag.Int("height", 6, "the image height in pixels")
+	animation := flag.String("gif", "", "write the layer stack composition as an animated GIF into `file`")
+	frames := flag.String("frames", "", "write the layer stack composition as PNG images into `directory`")
+	raw := flag.Bool("raw", false, "show each added layer next to the composition in -gif and -frames")
+	delay := flag.Int("delay", 10, "the GIF frames `delay` in hundredths of a second")
+	detect := flag.Bool("detect", false, "infer the image dimensions instead of using -width and -height")
+	flag.Parse()
+	if *encode != "" {
+		opts.Rand = rand.New(rand.NewSource(*seed))
+		if err := encodeImage(*encode, opts); err != nil {
+			log.Fatalf("encoding error: %s\n", err)
+		}
+		return
+	}
+	scheme := DefaultScheme
+	if *palette != "" {
+		var err error
+		if scheme, err = ParseScheme(*palette); err != nil {
+			log.Fatalf("palette error: %s\n", err)
+		}
+	}
+	pixels, err := scheme.ReadPixels(os.Stdin)
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	if *detect {
+		candidates, err := scheme.Detect(pixels)
+		if err != nil {
+			log.Fatalf("Detect(): %s\n", err)
+		}
+		*width, *height = candidates[0].Width, candidates[0].Height
+		fmt.Printf("The image dimensions are likely %dx%d.\n", *width, *height)
+	}
+	layers, err := Layers(*width, *height, pixels)
 	if err != nil {
 		log.Fatalf("input error: %s\n", err)
 	}
@@ -139,37 +172,157 @@ func main() {
 	}
 	one, two := l.Count(1), l.Count(2)
 	fmt.Printf("The number of 1 digits multiplied by the number of 2 digits is %v * %v = %v.\n", one, two, one*two)
-	flat, err := Flatten(layers)
+	flat, err := scheme.Flatten(layers)
 	if err != nil {
 		log.Fatalf("Flatten(): %s\n", err)
 	}
-	fmt.Printf("The message after decoding the image is:\n\n%v\n", flat)
+	drawing := flat.String()
+	if *palette != "" {
+		drawing = scheme.Format(flat)
+	}
+	fmt.Printf("The message after decoding the image is:\n\n%v\n", drawing)
+	switch text, err := ocr.Recognize(scheme.Bitmap(flat)); {
+	case err != nil:
+		log.Printf("Recognize(): %s\n", err)
+	case text == "":
+		fmt.Println("in which no text was recognized.")
+	default:
+		fmt.Printf("which reads %s.\n", text)
+	}
+	if *output != "" {
+		if err := WriteImage(*output, flat, *scale, scheme.Palette()); err != nil {
+			log.Fatalf("image error: %s\n", err)
+		}
+	}
+	fopts := FrameOptions{Scale: *scale, Raw: *raw, Delay: *delay, Scheme: scheme}
+	if *animation != "" {
+		if err := writeGIF(*animation, layers, fopts); err != nil {
+			log.Fatalf("animation error: %s\n", err)
+		}
+	}
+	if *frames != "" {
+		if err := WriteFrames(*frames, layers, fopts); err != nil {
+			log.Fatalf("frames error: %s\n", err)
+		}
+	}
 }
 
-// Parse the Space Image Format into its pixels layers.
-// It returns the Layer stack and any read or parsing error encountered.
-func Parse(width int, height int, r io.Reader) ([]Layer, error) {
-	// Scan all the pixels
-	scanner := bufio.NewScanner(r)
-	scanner.Split(bufio.ScanBytes)
-	var pixels []Pixel
-	for scanner.Scan() {
-		b := scanner.Text()
-		if b == "\n" {
-			continue // ignore newlines
-		}
-		i, err := strconv.ParseUint(scanner.Text(), 10, 64)
-		if err != nil {
-			return nil, err
+// stats parse the Space Image Format provided on stdin and display the
+// statistics of every layer, see Scheme.Analyze.
+func stats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	width := fs.Int("width", 25, "the image width in pixels")
+	height := fs.Int("height", 6, "the image height in pixels")
+	palette := fs.String("palette", "", "the `inks` of each pixel value as comma separated RRGGBB[AA][:opaque|clear|under]")
+	format := fs.String("format", "table", "the output `format`: table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s stats [-format format] [-palette inks] [-width n] [-height n] < input\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	scheme := DefaultScheme
+	if *palette != "" {
+		var err error
+		if scheme, err = ParseScheme(*palette); err != nil {
+			log.Fatalf("palette error: %s\n", err)
 		}
-		pixels = append(pixels, Pixel(i))
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	layers, err := scheme.Parse(*width, *height, os.Stdin)
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	a, err := scheme.Analyze(layers)
+	if err != nil {
+		log.Fatalf("Analyze(): %s\n", err)
+	}
+	switch *format {
+	case "table":
+		err = a.WriteTable(os.Stdout)
+	case "json":
+		err = a.WriteJSON(os.Stdout)
+	default:
+		log.Fatalf("unsupported output format %q\n", *format)
+	}
+	if err != nil {
+		log.Fatalf("output error: %s\n", err)
+	}
+}
+
+// writeGIF write the layer stack composition as an animated GIF into the file
+// at path.
+func writeGIF(path string, layers []Layer, opts FrameOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteGIF(f, layers, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodeImage write the Space Image Format of the image in the file at path on
+// stdout.
+func encodeImage(path string, opts EncodeOptions) error {
+	img, err := ReadImage(path)
+	if err != nil {
+		return err
+	}
+	layers, err := Encode(img, opts)
+	if err != nil {
+		return err
+	}
+	return WriteSIF(os.Stdout, layers)
+}
+
+// SyntaxError is returned when parsing a malformed Space Image Format.
+type SyntaxError struct {
+	// Offset is the byte offset in the input of an invalid pixel or of the
+	// first leftover digit. When returned by Layers, which has no input, it
+	// is the index of the first leftover pixel instead.
+	Offset int
+	Msg    string
+}
+
+// Error implements the error interface for SyntaxError.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+// Parse the Space Image Format into its pixels layers.
+// It returns the Layer stack and any read or parsing error encountered,
+// including a *SyntaxError when the digits count is not a multiple of the
+// layer size.
+func Parse(width int, height int, r io.Reader) ([]Layer, error) {
+	return DefaultScheme.Parse(width, height, r)
+}
+
+// ReadPixels read every pixel of a Space Image Format. Newlines are ignored.
+// It returns the pixels and any read error encountered, or a *SyntaxError
+// when an invalid pixel is found.
+func ReadPixels(r io.Reader) ([]Pixel, error) {
+	return DefaultScheme.ReadPixels(r)
+}
+
+// Layers slice the given pixels into layers of the provided dimensions. It
+// returns the Layer stack and an error when the dimensions are invalid, or a
+// *SyntaxError when the pixels count is not a multiple of the layer size, its
+// Offset being the index of the first leftover pixel.
+func Layers(width, height int, pixels []Pixel) ([]Layer, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid dimensions %dx%d", width, height)
 	}
-	// Create as many layers as needed, slicing the pixels.
 	lpc := width * height    // layer pixel count
 	tlc := len(pixels) / lpc // total layer count
+	if left := len(pixels) % lpc; left != 0 {
+		msg := fmt.Sprintf("%d leftover digits after %d layers of %dx%d", left, tlc, width, height)
+		return nil, &SyntaxError{tlc * lpc, msg}
+	}
 	layers := make([]Layer, tlc)
 	for i := 0; i < tlc; i++ {
 		chunk := pixels[i*lpc : (i+1)*lpc]
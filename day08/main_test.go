@@ -3,6 +3,8 @@ package main
 import (
 	"strings"
 	"testing"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
 )
 
 var encoded = "0222112222120000"
@@ -36,6 +38,13 @@ func TestFlatten(t *testing.T) {
 	} else if !LayerEquals(flat, want) {
 		t.Errorf("Flatten(%v) = %v; want = %v", encoded, flat, want)
 	}
+	// pixels transparent in every layer are Black.
+	flat, err = Flatten([]Layer{{2, 1, []Pixel{Trans, Trans}}, {2, 1, []Pixel{Trans, White}}})
+	if err != nil {
+		t.Errorf("Flatten() error: %s", err)
+	} else if want := (Layer{2, 1, []Pixel{Black, White}}); !LayerEquals(flat, want) {
+		t.Errorf("Flatten() = %v; want = %v", flat, want)
+	}
 }
 
 // LayerEquals returns true if the two given layers are the same, false
@@ -51,3 +60,36 @@ func LayerEquals(a, b Layer) bool {
 	}
 	return true
 }
+
+func TestBitmap(t *testing.T) {
+	// An L drawn on the bottom layer, partially hidden by a Black top layer.
+	top := Layer{4, 6, []Pixel{
+		2, 2, 2, 2,
+		2, 2, 2, 2,
+		2, 2, 2, 2,
+		2, 2, 2, 2,
+		2, 2, 2, 2,
+		2, 2, 2, 0,
+	}}
+	bottom := Layer{4, 6, []Pixel{
+		1, 0, 0, 0,
+		1, 0, 0, 0,
+		1, 0, 0, 0,
+		1, 0, 0, 0,
+		1, 0, 0, 0,
+		1, 1, 1, 1,
+	}}
+	flat, err := Flatten([]Layer{top, bottom})
+	if err != nil {
+		t.Fatalf("Flatten() error: %s", err)
+	}
+	if got := flat.Bitmap().String(); got != "#...\n#...\n#...\n#...\n#...\n###.\n" {
+		t.Errorf("Bitmap() =\n%s", got)
+	}
+	if _, err := ocr.Recognize(flat.Bitmap()); err == nil {
+		t.Errorf("Recognize() error = nil; want an unrecognized glyph")
+	}
+	if text, err := ocr.Recognize(bottom.Bitmap()); err != nil || text != "L" {
+		t.Errorf("Recognize() = %q, %v; want %q", text, err, "L")
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
+)
+
+// MaxInks is the maximum count of inks in a Scheme, one per digit.
+const MaxInks = 10
+
+// Blend is the rule deciding how a Pixel combines with the pixels below it in
+// the layer stack.
+type Blend uint8
+
+const (
+	// Opaque pixels hide the pixels below them.
+	Opaque Blend = iota
+	// Clear pixels are transparent, showing the pixels below them.
+	Clear
+	// Under pixels are only visible when no Opaque pixel is below them, like
+	// a background.
+	Under
+)
+
+// blendNames are the names of every Blend, as understood by ParseScheme.
+var blendNames = [...]string{
+	Opaque: "opaque",
+	Clear:  "clear",
+	Under:  "under",
+}
+
+// String implements Stringer for Blend.
+func (b Blend) String() string {
+	if int(b) < len(blendNames) {
+		return blendNames[b]
+	}
+	return fmt.Sprintf("Blend(%d)", b)
+}
+
+// Ink describe how a Pixel value is drawn.
+type Ink struct {
+	Color color.Color
+	Blend Blend
+}
+
+// Scheme map each Pixel value to its Ink, i.e. the Ink at index 0 is the one
+// of the Pixel value 0 etc. Pixel values without an Ink are invalid.
+type Scheme []Ink
+
+// DefaultScheme is the Scheme of the puzzle, where Black and White are
+// opaque and Trans is transparent.
+var DefaultScheme = Scheme{
+	Black: {color.Black, Opaque},
+	White: {color.White, Opaque},
+	Trans: {color.Transparent, Clear},
+}
+
+// Validate returns an error when the Scheme has no Ink, more than MaxInks or
+// an invalid Ink.
+func (s Scheme) Validate() error {
+	if len(s) == 0 || len(s) > MaxInks {
+		return fmt.Errorf("invalid ink count %d, want 1 to %d", len(s), MaxInks)
+	}
+	for i, ink := range s {
+		if ink.Color == nil {
+			return fmt.Errorf("ink %d has no color", i)
+		}
+		if int(ink.Blend) >= len(blendNames) {
+			return fmt.Errorf("ink %d has an invalid blend %v", i, ink.Blend)
+		}
+	}
+	return nil
+}
+
+// Palette returns the color of each Pixel value, suitable for the Layer
+// renderers.
+func (s Scheme) Palette() color.Palette {
+	p := make(color.Palette, len(s))
+	for i, ink := range s {
+		p[i] = ink.Color
+	}
+	return p
+}
+
+// ReadPixels read every pixel of a Space Image Format like the package level
+// ReadPixels, rejecting the pixel values without an Ink in the Scheme.
+func (s Scheme) ReadPixels(r io.Reader) ([]Pixel, error) {
+	br := bufio.NewReader(r)
+	var pixels []Pixel
+	for offset := 0; ; offset++ {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return pixels, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case b == '\n' || b == '\r':
+			continue // ignore newlines
+		case b < '0' || b > '9':
+			return nil, &SyntaxError{offset, fmt.Sprintf("invalid pixel %q", b)}
+		case int(b-'0') >= len(s):
+			return nil, &SyntaxError{offset, fmt.Sprintf("invalid pixel value %c", b)}
+		}
+		pixels = append(pixels, Pixel(b-'0'))
+	}
+}
+
+// Parse the Space Image Format into its pixels layers like the package level
+// Parse, rejecting the pixel values without an Ink in the Scheme.
+func (s Scheme) Parse(width int, height int, r io.Reader) ([]Layer, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	pixels, err := s.ReadPixels(bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	layers, err := Layers(width, height, pixels)
+	var serr *SyntaxError
+	if errors.As(err, &serr) {
+		// Layers only knows the index of the first leftover pixel, find its
+		// byte offset by skipping the newlines.
+		i := serr.Offset
+		for offset, b := range input {
+			if b == '\n' || b == '\r' {
+				continue
+			}
+			if i == 0 {
+				return nil, &SyntaxError{offset, serr.Msg}
+			}
+			i--
+		}
+	}
+	return layers, err
+}
+
+// Flatten take a stack of layer and combine them from the top (first) to the
+// bottom (last) according to the Blend of each Pixel value. Each pixel of the
+// combined Layer is the topmost Opaque pixel, or the topmost Under pixel when
+// there is no Opaque one, or else the zero Pixel. It return the combined
+// Layer and an error if layers is empty or when a Pixel has no Ink.
+func (s Scheme) Flatten(layers []Layer) (Layer, error) {
+	return s.compose(layers, false)
+}
+
+// compose combine the layers like Flatten. When transparent is true, the
+// pixels Clear in every layer are left as in the first layer instead of being
+// the zero Pixel, so that partial compositions keep their transparency.
+func (s Scheme) compose(layers []Layer, transparent bool) (Layer, error) {
+	var flat Layer
+	if len(layers) == 0 {
+		return flat, errors.New("empty layer stack")
+	}
+	// Create the flat image layer using the first layer's dimensions.
+	width, height := layers[0].width, layers[0].height
+	flat, err := NewLayer(width, height, make([]Pixel, width*height))
+	if err != nil {
+		return flat, err
+	}
+	// Black being the zero-value for Pixel, we start with an all-black Layer
+	// here and only set the decided pixels.
+	for i := range flat.pixels {
+		d, err := s.decide(layers, i)
+		if err != nil {
+			return flat, err
+		}
+		switch {
+		case d >= 0:
+			flat.pixels[i] = layers[d].pixels[i]
+		case transparent:
+			flat.pixels[i] = layers[0].pixels[i]
+		}
+	}
+	return flat, nil
+}
+
+// decide returns the index of the layer whose pixel at index i is visible
+// once layers are flattened, see Flatten, or -1 when every pixel there is
+// Clear. It returns an error when a Pixel has no Ink.
+func (s Scheme) decide(layers []Layer, i int) (int, error) {
+	under := -1 // the topmost layer having an Under pixel
+	for j, l := range layers {
+		// XXX: we assume here that every layer in layers has the same
+		// dimensions as the first one.
+		p := l.pixels[i]
+		if int(p) >= len(s) {
+			return 0, fmt.Errorf("pixel %d has no ink", p)
+		}
+		switch s[p].Blend {
+		case Opaque:
+			return j, nil
+		case Under:
+			if under < 0 {
+				under = j
+			}
+		}
+	}
+	return under, nil
+}
+
+// Histogram returns the count of pixels of each value in the Layer, indexed
+// by Pixel value. It returns an error when a Pixel has no Ink.
+func (s Scheme) Histogram(l Layer) ([]int, error) {
+	h := make([]int, len(s))
+	for i, p := range l.pixels {
+		if int(p) >= len(s) {
+			return nil, fmt.Errorf("pixel %d at (%d, %d) has no ink", p, i%l.width, i/l.width)
+		}
+		h[p]++
+	}
+	return h, nil
+}
+
+// Bitmap returns the Layer as a monochrome image where the pixels of a
+// bright color, i.e. whose luminance over a white background is at least
+// mid-gray like in EncodePBM, are lit. Clear pixels and pixels without an Ink
+// are off.
+func (s Scheme) Bitmap(l Layer) ocr.Bitmap {
+	lit := make([]bool, len(s))
+	for i, ink := range s {
+		lit[i] = ink.Blend != Clear && gray(ink.Color) >= 0x80
+	}
+	b := make(ocr.Bitmap, l.height)
+	for y := range b {
+		b[y] = make([]bool, l.width)
+		for x := range b[y] {
+			p := l.pixels[y*l.width+x]
+			b[y][x] = int(p) < len(lit) && lit[p]
+		}
+	}
+	return b
+}
+
+// Format returns the Layer drawn with the Scheme's colors using ANSI
+// escape sequences, Clear pixels being left blank.
+func (s Scheme) Format(l Layer) string {
+	var buf bytes.Buffer
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			p := l.pixels[y*l.width+x]
+			switch {
+			case int(p) >= len(s):
+				buf.WriteString("??")
+			case s[p].Blend == Clear:
+				buf.WriteString("  ")
+			default:
+				c := color.NRGBAModel.Convert(s[p].Color).(color.NRGBA)
+				fmt.Fprintf(&buf, "\x1b[48;2;%d;%d;%dm  \x1b[0m", c.R, c.G, c.B)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// ParseScheme parse a comma separated list of inks, the first one being the
+// Ink of the Pixel value 0, the second one of the Pixel value 1 etc. Each
+// Ink is a color as understood by ParsePalette, optionally followed by a
+// colon and its Blend name. Without Blend, fully transparent colors are Clear
+// and the others are Opaque. It returns the Scheme and any parsing error
+// encountered.
+func ParseScheme(s string) (Scheme, error) {
+	var scheme Scheme
+	for _, spec := range strings.Split(s, ",") {
+		hex, name := spec, ""
+		if i := strings.IndexByte(spec, ':'); i >= 0 {
+			hex, name = spec[:i], strings.TrimSpace(spec[i+1:])
+		}
+		p, err := ParsePalette(hex)
+		if err != nil {
+			return nil, err
+		}
+		ink := Ink{Color: p[0]}
+		if _, _, _, a := ink.Color.RGBA(); a == 0 {
+			ink.Blend = Clear
+		}
+		if name != "" {
+			ink.Blend = Blend(len(blendNames))
+			for b, n := range blendNames {
+				if n == name {
+					ink.Blend = Blend(b)
+				}
+			}
+			if int(ink.Blend) == len(blendNames) {
+				return nil, fmt.Errorf("invalid blend %q", name)
+			}
+		}
+		scheme = append(scheme, ink)
+	}
+	if err := scheme.Validate(); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
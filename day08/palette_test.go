@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
+)
+
+// testScheme has a red background (3) and a blue ink (4) along with the
+// DefaultScheme's inks.
+var testScheme = Scheme{
+	Black: {color.Black, Opaque},
+	White: {color.White, Opaque},
+	Trans: {color.Transparent, Clear},
+	3:     {color.NRGBA{R: 0xff, A: 0xff}, Under},
+	4:     {color.NRGBA{B: 0xff, A: 0xff}, Opaque},
+}
+
+func TestSchemeFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []Layer
+		want   []Pixel
+	}{
+		{"opaque", []Layer{
+			{4, 1, []Pixel{Trans, 4, Trans, Black}},
+			{4, 1, []Pixel{4, White, Trans, White}},
+		}, []Pixel{4, 4, Black, Black}},
+		{"under", []Layer{
+			{4, 1, []Pixel{3, 3, 3, Trans}},
+			{4, 1, []Pixel{White, Trans, Trans, 3}},
+			{4, 1, []Pixel{Trans, Trans, 4, Trans}},
+		}, []Pixel{White, 3, 4, 3}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			flat, err := testScheme.Flatten(tc.layers)
+			if err != nil {
+				t.Fatalf("Flatten() error: %s", err)
+			}
+			if !reflect.DeepEqual(flat.pixels, tc.want) {
+				t.Errorf("Flatten() = %v; want %v", flat.pixels, tc.want)
+			}
+		})
+	}
+	if _, err := DefaultScheme.Flatten([]Layer{{1, 1, []Pixel{3}}}); err == nil {
+		t.Error("Flatten() of a pixel without ink succeeded; want an error")
+	}
+}
+
+func TestSchemeReadPixels(t *testing.T) {
+	pixels, err := testScheme.ReadPixels(strings.NewReader("0134\n"))
+	if err != nil {
+		t.Fatalf("ReadPixels() error: %s", err)
+	}
+	if want := []Pixel{0, 1, 3, 4}; !reflect.DeepEqual(pixels, want) {
+		t.Errorf("ReadPixels() = %v; want %v", pixels, want)
+	}
+	_, err = testScheme.ReadPixels(strings.NewReader("0125"))
+	var serr *SyntaxError
+	if !errors.As(err, &serr) || serr.Offset != 3 {
+		t.Errorf("ReadPixels() error = %v; want a *SyntaxError at offset 3", err)
+	}
+}
+
+func TestSchemeHistogram(t *testing.T) {
+	h, err := testScheme.Histogram(Layer{3, 2, []Pixel{0, 3, 3, 4, 3, 1}})
+	if err != nil {
+		t.Fatalf("Histogram() error: %s", err)
+	}
+	if want := []int{1, 1, 0, 3, 1}; !reflect.DeepEqual(h, want) {
+		t.Errorf("Histogram() = %v; want %v", h, want)
+	}
+	if _, err := DefaultScheme.Histogram(Layer{1, 1, []Pixel{9}}); err == nil {
+		t.Error("Histogram() of a pixel without ink succeeded; want an error")
+	}
+}
+
+func TestSchemeBitmap(t *testing.T) {
+	// inverted draws Black pixels in white and White pixels in black.
+	inverted := Scheme{
+		Black: {color.White, Opaque},
+		White: {color.Black, Opaque},
+		Trans: {color.White, Clear},
+	}
+	l := Layer{4, 1, []Pixel{Black, White, Trans, 3}}
+	want := ocr.Bitmap{{true, false, false, false}}
+	if got := inverted.Bitmap(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("Bitmap() = %v; want %v", got, want)
+	}
+}
+
+func TestSchemeFormat(t *testing.T) {
+	l := Layer{2, 2, []Pixel{Black, 4, Trans, 9}}
+	want := "\x1b[48;2;0;0;0m  \x1b[0m\x1b[48;2;0;0;255m  \x1b[0m\n" +
+		"  ??\n"
+	if got := testScheme.Format(l); got != want {
+		t.Errorf("Format() = %q; want %q", got, want)
+	}
+}
+
+func TestParseScheme(t *testing.T) {
+	s, err := ParseScheme("000000,ffffff,00000000,ff0000:under,0000ff80")
+	if err != nil {
+		t.Fatalf("ParseScheme() error: %s", err)
+	}
+	want := []Blend{Opaque, Opaque, Clear, Under, Opaque}
+	if len(s) != len(want) {
+		t.Fatalf("ParseScheme() got %d inks; want %d", len(s), len(want))
+	}
+	for i, b := range want {
+		if s[i].Blend != b {
+			t.Errorf("ParseScheme()[%d].Blend = %v; want %v", i, s[i].Blend, b)
+		}
+	}
+	if c := s[4].Color; c != (color.NRGBA{B: 0xff, A: 0x80}) {
+		t.Errorf("ParseScheme()[4].Color = %v; want %v", c, color.NRGBA{B: 0xff, A: 0x80})
+	}
+	for _, bad := range []string{"000000:over", "fff", strings.Repeat("000000,", 10) + "000000"} {
+		if _, err := ParseScheme(bad); err == nil {
+			t.Errorf("ParseScheme(%q) error = nil; want an error", bad)
+		}
+	}
+}
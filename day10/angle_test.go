@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+)
+
+// vector is a random non-zero LineOfSight for testing/quick. Its coordinates
+// are small enough for Angle to be precise, and often tiny so that equal and
+// axis-aligned lines of sight are generated too.
+type vector LineOfSight
+
+// Generate implements quick.Generator for vector.
+func (vector) Generate(rng *rand.Rand, size int) reflect.Value {
+	bound := 1000
+	if rng.Intn(2) == 0 {
+		bound = 2
+	}
+	for {
+		x, y := rng.Intn(2*bound+1)-bound, rng.Intn(2*bound+1)-bound
+		if x != 0 || y != 0 {
+			los := Asteroid{}.BeamShot(Asteroid{x, y}).LineOfSight
+			return reflect.ValueOf(vector(los))
+		}
+	}
+}
+
+func TestLessProperties(t *testing.T) {
+	properties := map[string]interface{}{
+		"float ordering": func(a, b vector) bool {
+			l, o := LineOfSight(a), LineOfSight(b)
+			return l.Less(o) == (l.Angle() < o.Angle())
+		},
+		"irreflexive": func(a vector) bool {
+			return !LineOfSight(a).Less(LineOfSight(a))
+		},
+		"total": func(a, b vector) bool {
+			l, o := LineOfSight(a), LineOfSight(b)
+			return l == o || l.Less(o) != o.Less(l)
+		},
+		"transitive": func(a, b, c vector) bool {
+			l, m, o := LineOfSight(a), LineOfSight(b), LineOfSight(c)
+			return !l.Less(m) || !m.Less(o) || l.Less(o)
+		},
+	}
+	for name, prop := range properties {
+		cfg := &quick.Config{MaxCount: 10000, Rand: rand.New(rand.NewSource(1))}
+		if err := quick.Check(prop, cfg); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+}
+
+func TestLessNearlyCollinear(t *testing.T) {
+	// Both lines of sight point right and down and their angles only differ
+	// by about 1e-18 radian, which is beyond the Angle precision.
+	const n = 1000000000
+	a, b := LineOfSight{n, n - 1}, LineOfSight{n - 1, n - 2}
+	if a.Angle() != b.Angle() {
+		t.Errorf("Angle() = %v and %v; want the same approximation", a.Angle(), b.Angle())
+	}
+	// a is steeper than b, i.e. further clockwise from up.
+	if !b.Less(a) || a.Less(b) {
+		t.Errorf("%v.Less(%v) = %t; want true", b, a, b.Less(a))
+	}
+}
+
+func TestLessSort(t *testing.T) {
+	// the lines of sight in clockwise order from up.
+	want := []LineOfSight{
+		{0, -1}, {1, -2}, {1, -1}, {2, -1}, {1, 0}, {2, 1}, {1, 1}, {1, 2},
+		{0, 1}, {-1, 2}, {-1, 1}, {-2, 1}, {-1, 0}, {-2, -1}, {-1, -1}, {-1, -2},
+	}
+	got := make([]LineOfSight, len(want))
+	for i, j := range rand.New(rand.NewSource(1)).Perm(len(want)) {
+		got[i] = want[j]
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].Less(got[j])
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted = %v; want %v", got, want)
+	}
+	if zero := (LineOfSight{}); !zero.Less(want[0]) || want[0].Less(zero) {
+		t.Errorf("the zero LineOfSight does not come first")
+	}
+}
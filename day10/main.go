@@ -2,13 +2,13 @@ package main
 
 import (
 	"bufio"
-	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"os"
-	"sort"
+	"strings"
 )
 
 // Asteroid is a celestial body in the asteroid belt.
@@ -35,12 +35,42 @@ func (a Asteroid) String() string {
 	return fmt.Sprintf("%d,%d", a.x, a.y)
 }
 
-// Angle of this line of sight, up being 0 and increasing clockwise.
+// Angle of this line of sight, up being 0 and increasing clockwise. Since it
+// is a floating point approximation, nearly collinear lines of sight may have
+// the same Angle; use Less to order them.
 func (l LineOfSight) Angle() float64 {
 	return 180 - (180/math.Pi)*math.Atan2(float64(l.x), float64(l.y))
 }
 
-// BeamShot compute and returns the shot needed to vaporize o from a.
+// Less reports whether l comes strictly before o when rotating clockwise from
+// up, i.e. whether l.Angle() < o.Angle() computed exactly. The zero
+// LineOfSight comes before any other. The comparison is exact as long as the
+// coordinates are within [-2^31, 2^31), so that their cross product fits in
+// an int64.
+func (l LineOfSight) Less(o LineOfSight) bool {
+	if hl, ho := l.half(), o.half(); hl != ho {
+		return hl < ho
+	}
+	// In the same half, l comes first when o is clockwise from it, the y
+	// axis pointing down.
+	return int64(l.x)*int64(o.y)-int64(l.y)*int64(o.x) > 0
+}
+
+// half returns 1 for the lines of sight with an Angle in [0, 180), 2 for the
+// ones in [180, 360) and 0 for the zero LineOfSight.
+func (l LineOfSight) half() int {
+	switch {
+	case l.x == 0 && l.y == 0:
+		return 0
+	case l.x > 0 || (l.x == 0 && l.y < 0):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// BeamShot compute and returns the shot needed to vaporize o from a. It is
+// the integer counterpart of Point.Shot.
 func (a Asteroid) BeamShot(o Asteroid) BeamShot {
 	dx, dy := o.x-a.x, o.y-a.y
 	div := gcd(dx, dy)
@@ -68,54 +98,13 @@ func (a Asteroid) Detect(asteroids []Asteroid) int {
 }
 
 // Vaporize returns a channel of Asteroid to be vaporized (in order) by a giant
-// laser installed at the given station.
+// laser installed at the given station, starting up and rotating clockwise.
 func Vaporize(station Asteroid, asteroids []Asteroid) <-chan Asteroid {
 	victims := make(chan Asteroid)
 	go func() {
 		defer close(victims)
-		// create a BeamShot for every asteroid that is going to be vaporized.
-		// The ring represent the victims indexed by their shooting angle.
-		ring := make(map[LineOfSight][]*BeamShot)
-		// count how many asteroid we're shooting at, because asteroids may contain
-		// the station which we obviously don't want to vaporize.
-		n := 0
-		for _, o := range asteroids {
-			if o != station {
-				shot := station.BeamShot(o)
-				ring[shot.LineOfSight] = append(ring[shot.LineOfSight], &shot)
-				n++
-			}
-		}
-
-		// because the laser only has enough power to vaporize one asteroid at a
-		// time before continuing its rotation, the victims are sorted by distance
-		// (closest first) for every shoot angle.
-		for _, shots := range ring {
-			sort.Slice(shots, func(i, j int) bool {
-				return shots[i].Distance < shots[j].Distance
-			})
-		}
-
-		// build and sort the angles at which the laster is going to BeamShot.
-		lines := make([]LineOfSight, 0, len(ring))
-		for k := range ring {
-			lines = append(lines, k)
-		}
-		sort.Slice(lines, func(i, j int) bool {
-			return lines[i].Angle() < lines[j].Angle()
-		})
-
-		// vaporize the victims in order. We rotate through every BeamShot
-		// angles and pick the closest Asteroid to be seen until there are none
-		// left.
-		for n > 0 {
-			for _, l := range lines {
-				if shots := ring[l]; len(shots) > 0 {
-					victims <- shots[0].To
-					ring[l] = shots[1:]
-					n--
-				}
-			}
+		for hit := range (Sweep{Stations: []Asteroid{station}}).Run(asteroids) {
+			victims <- hit.To
 		}
 	}()
 	return victims
@@ -124,24 +113,44 @@ func Vaporize(station Asteroid, asteroids []Asteroid) <-chan Asteroid {
 // BestLocation find the asteroid which would be the best place to build a new
 // monitoring station. It returns the asteroid found to be the best, the count
 // of other asteroids in line of sight from it, and an error when asteroids is
-// empty.
+// empty. When several asteroids are the best, the first one is returned.
 func BestLocation(asteroids []Asteroid) (Asteroid, int, error) {
-	max := 0
-	loc := Asteroid{}
-	if len(asteroids) == 0 {
-		return loc, max, errors.New("empty slice argument")
-	}
-	for i, a := range asteroids {
-		if n := a.Detect(asteroids); i == 0 || n > max {
-			loc, max = a, n
-		}
-	}
-	return loc, max, nil
+	return NewField(asteroids).BestLocation(0)
 }
 
 // main find and display the best asteroid to build a new monitoring station
-// and the count of other asteroids in line of sight from it.
+// and the count of other asteroids in line of sight from it, and then the nth
+// asteroid to be vaporized by a giant laser installed there.
 func main() {
+	var sweep Sweep
+	flag.Float64Var(&sweep.Start, "start", 0, "the laser start `angle` in degrees, up being 0 and increasing clockwise")
+	flag.BoolVar(&sweep.CounterClockwise, "ccw", false, "rotate the laser counter-clockwise")
+	flag.IntVar(&sweep.Limit, "limit", 0, "the maximum count of shots of each laser per rotation, unlimited when 0")
+	stations := flag.String("stations", "", "space separated `x,y` positions of additional lasers firing in lockstep")
+	nth := flag.Int("nth", 200, "the vaporized asteroid to display")
+	svg := flag.String("svg", "", "draw the map into `file` as an SVG image, highlighting the nth vaporized asteroid")
+	scale := flag.Int("scale", 20, "the SVG image size of a map position, in `n` by n pixels")
+	points := flag.Bool("csv", false, "read comma separated point coordinates instead of a map, and only find the best location")
+	space := flag.Bool("3d", false, "read 2D maps separated by a blank line as a 3D map, and only find the best location")
+	flag.Parse()
+	if *points || *space {
+		// the other flags set up the vaporization, which is only supported
+		// on 2D maps.
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name != "csv" && f.Name != "3d" {
+				log.Fatalf("flag error: -%s cannot be combined with -csv or -3d\n", f.Name)
+			}
+		})
+		if *points && *space {
+			log.Fatalf("flag error: -csv and -3d are mutually exclusive\n")
+		}
+		bestPoint(*space)
+		return
+	}
+	others, err := parsePositions(*stations)
+	if err != nil {
+		log.Fatalf("stations error: %s\n", err)
+	}
 	asteroids, err := Parse(os.Stdin)
 	if err != nil {
 		log.Fatalf("input error: %s\n", err)
@@ -153,14 +162,85 @@ func main() {
 	}
 	fmt.Printf("%d other asteroids can be detected from %v,\n", n, a)
 
-	victims := Vaporize(a, asteroids)
-	for i := 0; i < 199; i++ {
-		if _, ok := <-victims; !ok {
-			log.Fatalf("only %d asteroid(s) vaporized; expected at least 200\n", i)
+	sweep.Stations = append([]Asteroid{a}, others...)
+	if *svg != "" {
+		if err := writeSVG(*svg, a, asteroids, SVGOptions{Scale: *scale, Highlight: *nth, Sweep: sweep}); err != nil {
+			log.Fatalf("SVG error: %s\n", err)
+		}
+	}
+	i := 0
+	for hit := range sweep.Run(asteroids) {
+		if i++; i == *nth {
+			fmt.Printf("and the %s asteroid to be vaporized is at %v.\n", ordinal(i), hit.To)
+			if sweep.Start != 0 || sweep.CounterClockwise || sweep.Limit > 0 || len(others) > 0 {
+				fmt.Printf("It was vaporized by the laser at %v during its rotation %d.\n", hit.From, hit.Rotation)
+			}
+			return
+		}
+	}
+	log.Fatalf("only %d asteroid(s) vaporized; expected at least %d\n", i, *nth)
+}
+
+// bestPoint parse the points provided on stdin, either as CSV or as a 3D map,
+// and display the best point to build a new monitoring station and the count
+// of other points in line of sight from it.
+func bestPoint(space bool) {
+	parse := ParseCSV
+	if space {
+		parse = Parse3D
+	}
+	points, err := parse(os.Stdin)
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	p, n, err := BestPoint(points)
+	if err != nil {
+		log.Fatalf("BestPoint(): %s\n", err)
+	}
+	fmt.Printf("%d other asteroids can be detected from %v.\n", n, p)
+}
+
+// writeSVG draw the map of the given asteroids into the file at path, see
+// WriteSVG.
+func writeSVG(path string, station Asteroid, asteroids []Asteroid, opts SVGOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteSVG(f, station, asteroids, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parsePositions parse a space separated list of x,y positions. It returns
+// the asteroids at these positions and any parsing error encountered.
+func parsePositions(s string) ([]Asteroid, error) {
+	var asteroids []Asteroid
+	for _, pos := range strings.Fields(s) {
+		var a Asteroid
+		if _, err := fmt.Sscanf(pos, "%d,%d", &a.x, &a.y); err != nil {
+			return nil, fmt.Errorf("invalid position %q", pos)
 		}
+		asteroids = append(asteroids, a)
+	}
+	return asteroids, nil
+}
+
+// ordinal returns the English ordinal of n, e.g. "1st" for 1.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
 	}
-	vaporized := <-victims // the 200th
-	fmt.Printf("and the 200th asteroid to be vaporized is at %v.\n", vaporized)
+	return fmt.Sprintf("%d%s", n, suffix)
 }
 
 // Parse the map of asteroid in the region. It returns the complete asteroid
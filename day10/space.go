@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// Point is the position of an asteroid in a space of any dimension, with
+// arbitrary-precision rational coordinates. Missing coordinates are zero, so
+// that a Point is also a Point of every higher dimension.
+type Point []*big.Rat
+
+// Direction is the LineOfSight of any dimension, i.e. the direction from one
+// Point to another. It is made of the comma separated coordinates of the
+// smallest integer vector in that direction, which hold the invariant that
+// their gcd is 1. Trailing zero coordinates are omitted, so that a Direction
+// is the same in every dimension. The zero value represent the direction from
+// a Point to itself.
+type Direction string
+
+// Shot is the BeamShot of any dimension, from a Point to another.
+type Shot struct {
+	From, To Point
+	Direction
+	// Distance is the count of Direction vectors from From to To.
+	Distance *big.Rat
+}
+
+// Point returns the Asteroid's position as a Point.
+func (a Asteroid) Point() Point {
+	return Point{big.NewRat(int64(a.x), 1), big.NewRat(int64(a.y), 1)}
+}
+
+// coord returns the i-th coordinate of the Point.
+func (p Point) coord(i int) *big.Rat {
+	if i < len(p) {
+		return p[i]
+	}
+	return new(big.Rat)
+}
+
+// Equal reports whether p and o are at the same position.
+func (p Point) Equal(o Point) bool {
+	for i := 0; i < len(p) || i < len(o); i++ {
+		if p.coord(i).Cmp(o.coord(i)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the comma separated coordinates of the Point, matching the
+// Asteroid format for integer coordinates.
+func (p Point) String() string {
+	coords := make([]string, len(p))
+	for i, c := range p {
+		coords[i] = c.RatString()
+	}
+	return strings.Join(coords, ",")
+}
+
+// Shot compute and returns the shot needed to vaporize o from p, see reduce.
+func (p Point) Shot(o Point) Shot {
+	n := len(p)
+	if len(o) > n {
+		n = len(o)
+	}
+	delta := make([]*big.Rat, n)
+	for i := range delta {
+		delta[i] = new(big.Rat).Sub(o.coord(i), p.coord(i))
+	}
+	v, dist := reduce(delta)
+	return Shot{From: p, To: o, Direction: direction(v), Distance: dist}
+}
+
+// Direction returns the LineOfSight as a Direction.
+func (l LineOfSight) Direction() Direction {
+	return direction([]*big.Int{big.NewInt(int64(l.x)), big.NewInt(int64(l.y))})
+}
+
+// reduce returns the smallest integer vector in the direction of the
+// displacement delta, whose coordinates have a gcd of 1, and the count of such
+// vectors in delta. The displacement is scaled to integers by the least common
+// multiple of its denominators, and then divided by the gcd of its
+// coordinates. When delta is null, the vector is null too and the count is
+// zero.
+func reduce(delta []*big.Rat) ([]*big.Int, *big.Rat) {
+	den := big.NewInt(1) // least common multiple of the denominators
+	for _, r := range delta {
+		d := r.Denom()
+		g := new(big.Int).GCD(nil, nil, den, d)
+		den.Mul(den, new(big.Int).Quo(d, g))
+	}
+	v := make([]*big.Int, len(delta))
+	div := new(big.Int) // gcd of the scaled coordinates
+	for i, r := range delta {
+		v[i] = new(big.Int).Mul(r.Num(), new(big.Int).Quo(den, r.Denom()))
+		div.GCD(nil, nil, div, new(big.Int).Abs(v[i]))
+	}
+	if div.Sign() == 0 {
+		return v, new(big.Rat)
+	}
+	for _, c := range v {
+		c.Quo(c, div)
+	}
+	return v, new(big.Rat).SetFrac(div, den)
+}
+
+// direction returns the Direction of the integer vector v, see reduce.
+func direction(v []*big.Int) Direction {
+	n := len(v)
+	for n > 0 && v[n-1].Sign() == 0 {
+		n-- // omit the trailing zero coordinates
+	}
+	coords := make([]string, n)
+	for i := range coords {
+		coords[i] = v[i].String()
+	}
+	return Direction(strings.Join(coords, ","))
+}
+
+// Detect returns the count of others points in direct line of sight from p,
+// see Asteroid.Detect.
+func (p Point) Detect(points []Point) int {
+	detected := make(map[Direction]struct{}) // "set-like" map
+	for _, o := range points {
+		if !p.Equal(o) {
+			detected[p.Shot(o).Direction] = struct{}{}
+		}
+	}
+	return len(detected)
+}
+
+// BestPoint find the point which would be the best place to build a new
+// monitoring station, see BestLocation. It returns the point found to be the
+// best, the count of other points in line of sight from it, and an error
+// when points is empty.
+func BestPoint(points []Point) (Point, int, error) {
+	if len(points) == 0 {
+		return nil, 0, errors.New("empty slice argument")
+	}
+	loc, max := points[0], -1
+	for _, p := range points {
+		if n := p.Detect(points); n > max {
+			loc, max = p, n
+		}
+	}
+	return loc, max, nil
+}
+
+// ParseCSV parse a list of points, one per line as comma separated
+// coordinates. Each coordinate is either an integer, a decimal number or a
+// fraction like 1/3, and every point must have the same count of coordinates.
+// Lines starting with # are ignored. It returns the points and any read or
+// parsing error encountered.
+func ParseCSV(r io.Reader) ([]Point, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	var points []Point
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return points, nil
+		} else if err != nil {
+			return nil, err
+		}
+		p := make(Point, len(record))
+		for i, field := range record {
+			c, ok := new(big.Rat).SetString(strings.TrimSpace(field))
+			if !ok {
+				line, column := cr.FieldPos(i)
+				return nil, fmt.Errorf("line %d, column %d: invalid coordinate %q", line, column, field)
+			}
+			p[i] = c
+		}
+		points = append(points, p)
+	}
+}
+
+// Parse3D parse a map of asteroids in a 3D region, made of 2D maps (see
+// Parse) separated by a blank line, the first one being at z = 0. It returns
+// the complete asteroid list as points and any read or parsing error
+// encountered.
+func Parse3D(r io.Reader) ([]Point, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var points []Point
+	input = bytes.TrimRight(input, "\n")
+	for z, slice := range bytes.Split(input, []byte("\n\n")) {
+		asteroids, err := Parse(bytes.NewReader(slice))
+		if err != nil {
+			return nil, fmt.Errorf("slice %d: %w", z, err)
+		}
+		for _, a := range asteroids {
+			points = append(points, append(a.Point(), big.NewRat(int64(z), 1)))
+		}
+	}
+	return points, nil
+}
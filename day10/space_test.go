@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// point returns the Point of the given coordinates, each being parsed by
+// big.Rat.SetString.
+func point(coords ...string) Point {
+	p := make(Point, len(coords))
+	for i, c := range coords {
+		p[i], _ = new(big.Rat).SetString(c)
+	}
+	return p
+}
+
+func TestPointShot(t *testing.T) {
+	tests := []struct {
+		from, to  Point
+		direction Direction
+		distance  string
+	}{
+		{point("0", "0", "0"), point("2", "4", "6"), "1,2,3", "2"},
+		{point("1", "1", "1"), point("1", "-2", "1"), "0,-1", "3"},
+		{point("0", "0"), point("2", "0", "0"), "1", "2"},
+		{point("0", "0"), point("1/2", "3/4"), "2,3", "1/4"},
+		{point("0.5", "1"), point("2", "-0.5"), "1,-1", "3/2"},
+		{point("1", "2"), point("1", "2", "3"), "0,0,1", "3"},
+		{point("1", "2"), point("1", "2"), "", "0"},
+	}
+	for _, tc := range tests {
+		shot := tc.from.Shot(tc.to)
+		if shot.Direction != tc.direction {
+			t.Errorf("%v.Shot(%v).Direction = %q; want %q", tc.from, tc.to, shot.Direction, tc.direction)
+		}
+		if got := shot.Distance.RatString(); got != tc.distance {
+			t.Errorf("%v.Shot(%v).Distance = %s; want %s", tc.from, tc.to, got, tc.distance)
+		}
+	}
+}
+
+func TestPointDetect(t *testing.T) {
+	// the 2D points must detect as many points as the asteroids.
+	asteroids, err := Parse(strings.NewReader(strings.Trim(`
+.#..#
+.....
+#####
+....#
+...##
+`, "\n")))
+	if err != nil {
+		t.Fatalf("Parse() error: %s", err)
+	}
+	points := make([]Point, len(asteroids))
+	for i, a := range asteroids {
+		points[i] = a.Point()
+	}
+	for i, a := range asteroids {
+		if got, want := points[i].Detect(points), a.Detect(asteroids); got != want {
+			t.Errorf("%v.Detect() = %d; want %d", points[i], got, want)
+		}
+		for j, o := range asteroids {
+			got, want := points[i].Shot(points[j]).Direction, a.BeamShot(o).LineOfSight.Direction()
+			if got != want {
+				t.Errorf("%v.Shot(%v).Direction = %q; want %q", points[i], points[j], got, want)
+			}
+		}
+	}
+
+	// points of different dimensions in the same direction.
+	mixed := []Point{point("1", "0"), point("2", "0", "0")}
+	if n := point("0", "0").Detect(mixed); n != 1 {
+		t.Errorf("origin.Detect(%v) = %d; want 1", mixed, n)
+	}
+
+	// a 3x3x3 cube where the center hides nothing but the opposite corners.
+	space, err := Parse3D(strings.NewReader("###\n###\n###\n\n###\n###\n###\n\n###\n###\n###\n"))
+	if err != nil {
+		t.Fatalf("Parse3D() error: %s", err)
+	}
+	if len(space) != 27 {
+		t.Fatalf("Parse3D() got %d points; want 27", len(space))
+	}
+	if n := point("0", "0", "0").Detect(space); n != 19 {
+		t.Errorf("corner.Detect() = %d; want 19", n)
+	}
+	p, n, err := BestPoint(space)
+	if err != nil {
+		t.Fatalf("BestPoint() error: %s", err)
+	}
+	if p.String() != "1,1,1" || n != 26 {
+		t.Errorf("BestPoint() = %v, %d; want 1,1,1, 26", p, n)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	input := "# x, y, z\n1, 2, 3\n-1/3,0.25,1e2\n"
+	points, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() error: %s", err)
+	}
+	want := []Point{point("1", "2", "3"), point("-1/3", "1/4", "100")}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points; want %d", len(points), len(want))
+	}
+	for i := range want {
+		if !points[i].Equal(want[i]) {
+			t.Errorf("points[%d] = %v; want %v", i, points[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"1,2\n3\n", "1,x\n"} {
+		if _, err := ParseCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseCSV(%q) error = nil; want an error", bad)
+		}
+	}
+}
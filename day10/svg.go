@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// svgStyle is the style sheet of the SVG maps.
+const svgStyle = `
+line.sight { stroke: #6cf; stroke-width: 0.05; }
+circle.detected { fill: #fc6; }
+circle.hidden { fill: #999; }
+circle.station { fill: #d22; }
+circle.highlight { fill: none; stroke: #c0c; stroke-width: 0.12; }
+text { font: 0.35px sans-serif; fill: #000; text-anchor: middle; dominant-baseline: central; }
+`
+
+// SVGOptions control how WriteSVG draws a map.
+type SVGOptions struct {
+	// Scale is the size in pixels of a map position, at least one.
+	Scale int
+	// Highlight is the vaporization order number of the Asteroid to
+	// highlight, none when zero.
+	Highlight int
+	// Sweep is the lasers setting giving the vaporization order, its
+	// stations being drawn too. When it has no station, a single laser is
+	// installed at the station like Vaporize.
+	Sweep Sweep
+}
+
+// WriteSVG draw the map of the given asteroids as an SVG image into w. The
+// station is highlighted along with its lines of sight to the detected
+// asteroids, the hidden asteroids are shaded, and every Asteroid is labeled
+// with its vaporization order number. It returns an error when the options are
+// invalid, and any write error encountered.
+func WriteSVG(w io.Writer, station Asteroid, asteroids []Asteroid, opts SVGOptions) error {
+	if opts.Scale < 1 {
+		return fmt.Errorf("invalid scale %d", opts.Scale)
+	}
+	if len(asteroids) == 0 {
+		return errors.New("empty slice argument")
+	}
+	// the map dimensions, the positions starting at 0.
+	width, height := station.x+1, station.y+1
+	for _, a := range asteroids {
+		width, height = max(width, a.x+1), max(height, a.y+1)
+	}
+	// the closest Asteroid in every line of sight is detected, the others are
+	// hidden behind it.
+	closest := make(map[LineOfSight]BeamShot)
+	for _, a := range asteroids {
+		if a == station {
+			continue
+		}
+		shot := station.BeamShot(a)
+		if c, ok := closest[shot.LineOfSight]; !ok || shot.Distance < c.Distance {
+			closest[shot.LineOfSight] = shot
+		}
+	}
+	sweep := opts.Sweep
+	if len(sweep.Stations) == 0 {
+		sweep.Stations = []Asteroid{station}
+	}
+	stations := make(map[Asteroid]bool)
+	for _, st := range sweep.Stations {
+		stations[st] = true
+	}
+	order := make(map[Asteroid]int)
+	i := 0
+	for hit := range sweep.Run(asteroids) {
+		i++
+		order[hit.To] = i
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="-0.5 -0.5 %d %d">`+"\n",
+		width*opts.Scale, height*opts.Scale, width, height)
+	fmt.Fprintf(bw, "<style>%s</style>\n", svgStyle)
+	fmt.Fprintf(bw, `<rect x="-0.5" y="-0.5" width="%d" height="%d" fill="#fff"/>`+"\n", width, height)
+	for _, a := range asteroids {
+		if shot := station.BeamShot(a); a != station && closest[shot.LineOfSight].To == a {
+			fmt.Fprintf(bw, `<line class="sight" x1="%d" y1="%d" x2="%d" y2="%d"/>`+"\n", station.x, station.y, a.x, a.y)
+		}
+	}
+	drawn := sweep.Stations
+	if !stations[station] {
+		drawn = append([]Asteroid{station}, drawn...)
+		stations[station] = true
+	}
+	for _, st := range drawn {
+		fmt.Fprintf(bw, `<circle class="station" cx="%d" cy="%d" r="0.4"/>`+"\n", st.x, st.y)
+	}
+	for _, a := range asteroids {
+		if stations[a] {
+			continue
+		}
+		class := "hidden"
+		if closest[station.BeamShot(a).LineOfSight].To == a {
+			class = "detected"
+		}
+		fmt.Fprintf(bw, `<circle class="%s" cx="%d" cy="%d" r="0.3"/>`+"\n", class, a.x, a.y)
+		if n, ok := order[a]; ok {
+			if n == opts.Highlight {
+				fmt.Fprintf(bw, `<circle class="highlight" cx="%d" cy="%d" r="0.45"/>`+"\n", a.x, a.y)
+			}
+			fmt.Fprintf(bw, `<text x="%d" y="%d">%d</text>`+"\n", a.x, a.y, n)
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	asteroids, err := Parse(strings.NewReader(strings.Trim(`
+.#..#
+.....
+#####
+....#
+...##
+`, "\n")))
+	if err != nil {
+		t.Fatalf("Parse() error: %s", err)
+	}
+	station := Asteroid{3, 4}
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, station, asteroids, SVGOptions{Scale: 10, Highlight: 3}); err != nil {
+		t.Fatalf("WriteSVG() error: %s", err)
+	}
+
+	// count the elements by name and class, and collect the labels.
+	count := make(map[string]int)
+	var labels []string
+	dec := xml.NewDecoder(&buf)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			name := e.Name.Local
+			for _, attr := range e.Attr {
+				if attr.Name.Local == "class" {
+					name += "." + attr.Value
+				}
+				if e.Name.Local == "svg" && attr.Name.Local == "width" && attr.Value != "50" {
+					t.Errorf("svg width = %s; want 50", attr.Value)
+				}
+			}
+			count[name]++
+			if name == "text" {
+				if tok, err := dec.Token(); err == nil {
+					if cd, ok := tok.(xml.CharData); ok {
+						labels = append(labels, string(cd))
+					}
+				}
+			}
+		}
+	}
+	want := map[string]int{
+		"svg":              1,
+		"line.sight":       8,
+		"circle.station":   1,
+		"circle.detected":  8,
+		"circle.hidden":    1,
+		"circle.highlight": 1,
+		"text":             9,
+	}
+	for name, n := range want {
+		if count[name] != n {
+			t.Errorf("got %d %s; want %d", count[name], name, n)
+		}
+	}
+	sort.Strings(labels)
+	if got, want := strings.Join(labels, " "), "1 2 3 4 5 6 7 8 9"; got != want {
+		t.Errorf("labels = %q; want %q", got, want)
+	}
+
+	// the highlighted Asteroid follows the configured Sweep.
+	sweep := Sweep{Stations: []Asteroid{station}, CounterClockwise: true, Start: 90}
+	first := (<-sweep.Run(asteroids)).To
+	buf.Reset()
+	if err := WriteSVG(&buf, station, asteroids, SVGOptions{Scale: 1, Highlight: 1, Sweep: sweep}); err != nil {
+		t.Fatalf("WriteSVG() error: %s", err)
+	}
+	highlight := fmt.Sprintf(`<circle class="highlight" cx="%d" cy="%d"`, first.x, first.y)
+	if !strings.Contains(buf.String(), highlight) {
+		t.Errorf("WriteSVG() with a Sweep does not highlight %v", first)
+	}
+
+	if err := WriteSVG(&buf, station, asteroids, SVGOptions{}); err == nil {
+		t.Error("WriteSVG() with a zero scale succeeded; want an error")
+	}
+}
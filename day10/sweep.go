@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Sweep configure the giant lasers vaporizing the asteroids. The zero value
+// has no laser.
+type Sweep struct {
+	// Stations are the positions of the lasers, all rotating in lockstep.
+	// Stations are never vaporized.
+	Stations []Asteroid
+	// Start is the angle in degrees the lasers initially point to, up being 0
+	// and increasing clockwise. The lines of sight are ordered exactly, see
+	// LineOfSight.Less, but compared to Start using their Angle.
+	Start float64
+	// CounterClockwise tells whether the lasers rotate counter-clockwise
+	// instead of clockwise.
+	CounterClockwise bool
+	// Limit is the maximum count of shots fired by each laser during a
+	// rotation. When zero, the shots are unlimited.
+	Limit int
+}
+
+// Hit is an Asteroid vaporized by a laser.
+type Hit struct {
+	BeamShot
+	// Laser is the index of the station which vaporized the Asteroid.
+	Laser int
+	// Rotation is the laser's rotation during which the Asteroid was
+	// vaporized, the first one being 1.
+	Rotation int
+}
+
+// laser is the state of a Sweep's laser.
+type laser struct {
+	// ring are the victims indexed by their shooting angle, closest first.
+	ring map[LineOfSight][]*BeamShot
+	// lines are the angles at which the laser is going to shoot, in rotation
+	// order.
+	lines []LineOfSight
+	// positions are the position of each line in the rotation.
+	positions []position
+}
+
+// position is the place of a LineOfSight in a laser rotation.
+type position struct {
+	// wrapped tells whether the line is before the Start angle, i.e. only
+	// reached after the laser went through up.
+	wrapped bool
+	// line is the LineOfSight, mirrored for counter-clockwise rotations so
+	// that its clockwise order is the rotation order.
+	line LineOfSight
+}
+
+// less reports whether p comes strictly before o in the rotation.
+func (p position) less(o position) bool {
+	if p.wrapped != o.wrapped {
+		return !p.wrapped
+	}
+	return p.line.Less(o.line)
+}
+
+// Run returns a channel of Hit to be vaporized (in order) by the lasers. At
+// each angle, a laser vaporize the closest Asteroid not yet vaporized. When
+// several lasers shoot at the same angle from the Start, the first station
+// shoot first.
+func (s Sweep) Run(asteroids []Asteroid) <-chan Hit {
+	hits := make(chan Hit)
+	go func() {
+		defer close(hits)
+		stations := make(map[Asteroid]bool)
+		for _, st := range s.Stations {
+			stations[st] = true
+		}
+		// alive are the asteroids not yet vaporized, counting duplicates.
+		alive := make(map[Asteroid]int)
+		n := 0 // count of asteroids left to vaporize
+		for _, o := range asteroids {
+			if !stations[o] {
+				alive[o]++
+				n++
+			}
+		}
+		lasers := make([]laser, len(s.Stations))
+		for i, st := range s.Stations {
+			lasers[i] = s.aim(st, asteroids, stations)
+		}
+
+		for rotation := 1; n > 0 && len(lasers) > 0; rotation++ {
+			shots := make([]int, len(lasers)) // shots fired during this rotation
+			next := make([]int, len(lasers))  // index of the next line of each laser
+			for {
+				// pick the laser having the smallest angle to shoot at next.
+				k := -1
+				for i, l := range lasers {
+					if next[i] < len(l.lines) && (k < 0 || l.positions[next[i]].less(lasers[k].positions[next[k]])) {
+						k = i
+					}
+				}
+				if k < 0 {
+					break // end of the rotation
+				}
+				l := lasers[k]
+				line := l.lines[next[k]]
+				next[k]++
+				if s.Limit > 0 && shots[k] >= s.Limit {
+					continue
+				}
+				// skip the asteroids already vaporized by another laser.
+				victims := l.ring[line]
+				for len(victims) > 0 && alive[victims[0].To] == 0 {
+					victims = victims[1:]
+				}
+				if len(victims) == 0 {
+					l.ring[line] = victims
+					continue
+				}
+				hits <- Hit{BeamShot: *victims[0], Laser: k, Rotation: rotation}
+				alive[victims[0].To]--
+				l.ring[line] = victims[1:]
+				shots[k]++
+				n--
+			}
+		}
+	}()
+	return hits
+}
+
+// aim returns the laser installed at the given station, targeting every
+// asteroid but the stations.
+func (s Sweep) aim(station Asteroid, asteroids []Asteroid, stations map[Asteroid]bool) laser {
+	// create a BeamShot for every asteroid that is going to be vaporized.
+	l := laser{ring: make(map[LineOfSight][]*BeamShot)}
+	for _, o := range asteroids {
+		if !stations[o] {
+			shot := station.BeamShot(o)
+			l.ring[shot.LineOfSight] = append(l.ring[shot.LineOfSight], &shot)
+		}
+	}
+
+	// because a laser only has enough power to vaporize one asteroid at a
+	// time before continuing its rotation, the victims are sorted by distance
+	// (closest first) for every shoot angle.
+	for _, shots := range l.ring {
+		sort.Slice(shots, func(i, j int) bool {
+			return shots[i].Distance < shots[j].Distance
+		})
+	}
+
+	// build and sort the angles at which the laser is going to shoot, from
+	// the Start angle in rotation direction.
+	start := s.Start
+	if s.CounterClockwise {
+		start = -start
+	}
+	if start = math.Mod(start, 360); start < 0 {
+		start += 360
+	}
+	pos := make(map[LineOfSight]position, len(l.ring))
+	for k := range l.ring {
+		p := position{line: k}
+		if s.CounterClockwise {
+			p.line.x = -p.line.x
+		}
+		p.wrapped = p.line.Angle() < start
+		pos[k] = p
+		l.lines = append(l.lines, k)
+	}
+	sort.Slice(l.lines, func(i, j int) bool {
+		return pos[l.lines[i]].less(pos[l.lines[j]])
+	})
+	l.positions = make([]position, len(l.lines))
+	for i, los := range l.lines {
+		l.positions[i] = pos[los]
+	}
+	return l
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSweep(t *testing.T) {
+	// hit is a shortcut to build a Hit by the laser at the given station.
+	hit := func(laser int, station, victim Asteroid, rotation int) Hit {
+		return Hit{BeamShot: station.BeamShot(victim), Laser: laser, Rotation: rotation}
+	}
+	center := Asteroid{2, 2}
+	cross := []Asteroid{{2, 1}, {1, 2}, center, {3, 2}, {2, 3}}
+	tests := []struct {
+		name      string
+		sweep     Sweep
+		asteroids []Asteroid
+		want      []Hit
+	}{
+		{
+			name:      "clockwise",
+			sweep:     Sweep{Stations: []Asteroid{center}},
+			asteroids: cross,
+			want: []Hit{
+				hit(0, center, Asteroid{2, 1}, 1),
+				hit(0, center, Asteroid{3, 2}, 1),
+				hit(0, center, Asteroid{2, 3}, 1),
+				hit(0, center, Asteroid{1, 2}, 1),
+			},
+		},
+		{
+			name:      "counter-clockwise",
+			sweep:     Sweep{Stations: []Asteroid{center}, CounterClockwise: true},
+			asteroids: cross,
+			want: []Hit{
+				hit(0, center, Asteroid{2, 1}, 1),
+				hit(0, center, Asteroid{1, 2}, 1),
+				hit(0, center, Asteroid{2, 3}, 1),
+				hit(0, center, Asteroid{3, 2}, 1),
+			},
+		},
+		{
+			name:      "start",
+			sweep:     Sweep{Stations: []Asteroid{center}, Start: 135},
+			asteroids: cross,
+			want: []Hit{
+				hit(0, center, Asteroid{2, 3}, 1),
+				hit(0, center, Asteroid{1, 2}, 1),
+				hit(0, center, Asteroid{2, 1}, 1),
+				hit(0, center, Asteroid{3, 2}, 1),
+			},
+		},
+		{
+			name:      "limit",
+			sweep:     Sweep{Stations: []Asteroid{center}, Limit: 1},
+			asteroids: []Asteroid{{2, 0}, {2, 1}, center, {3, 2}},
+			want: []Hit{
+				hit(0, center, Asteroid{2, 1}, 1),
+				hit(0, center, Asteroid{2, 0}, 2),
+				hit(0, center, Asteroid{3, 2}, 3),
+			},
+		},
+		{
+			name:      "lockstep",
+			sweep:     Sweep{Stations: []Asteroid{{0, 0}, {2, 0}}},
+			asteroids: []Asteroid{{0, 0}, {1, 0}, {2, 0}, {3, 0}},
+			want: []Hit{
+				hit(0, Asteroid{0, 0}, Asteroid{1, 0}, 1),
+				hit(1, Asteroid{2, 0}, Asteroid{3, 0}, 1),
+			},
+		},
+		{
+			name:      "shared victims",
+			sweep:     Sweep{Stations: []Asteroid{{0, 0}, {0, 2}}},
+			asteroids: []Asteroid{{0, 0}, {1, 1}, {0, 2}, {2, 2}},
+			want: []Hit{
+				hit(1, Asteroid{0, 2}, Asteroid{1, 1}, 1),
+				hit(1, Asteroid{0, 2}, Asteroid{2, 2}, 1),
+			},
+		},
+		{
+			name:      "no laser",
+			asteroids: cross,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []Hit
+			for h := range tc.sweep.Run(tc.asteroids) {
+				got = append(got, h)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Run() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// Field is a visibility engine for a set of asteroids. Every displacement
+// between two asteroids of the Field is mapped once to its LineOfSight
+// through a table shared by all the stations, so that counting the asteroids
+// in line of sight boils down to array lookups instead of map insertions.
+type Field struct {
+	asteroids []Asteroid
+	// width and height are the dimensions of the asteroids bounding box.
+	width, height int
+	// lines map every displacement (dx, dy) between two positions of the
+	// bounding box to an identifier of its LineOfSight, see line.
+	lines []int32
+	// count is the number of distinct LineOfSight identifiers.
+	count int
+}
+
+// NewField build the visibility engine of the given asteroids.
+func NewField(asteroids []Asteroid) *Field {
+	f := &Field{asteroids: asteroids}
+	if len(asteroids) == 0 {
+		return f
+	}
+	minX, minY := asteroids[0].x, asteroids[0].y
+	maxX, maxY := minX, minY
+	for _, a := range asteroids[1:] {
+		minX, maxX = min(minX, a.x), max(maxX, a.x)
+		minY, maxY = min(minY, a.y), max(maxY, a.y)
+	}
+	f.width, f.height = maxX-minX+1, maxY-minY+1
+
+	// Assign a base identifier to every LineOfSight of the bottom right
+	// quadrant, i.e. to every displacement whose coordinates are coprime. A
+	// multiple k*(dx, dy) is visited after (dx, dy), since it has either a
+	// greater dy or the same dy and a greater dx, so a displacement not yet
+	// identified when visited is a LineOfSight.
+	quadrant := make([]int32, f.width*f.height)
+	for i := range quadrant {
+		quadrant[i] = -1
+	}
+	bases := 0
+	for dy := 0; dy < f.height; dy++ {
+		for dx := 0; dx < f.width; dx++ {
+			if (dx == 0 && dy == 0) || quadrant[dy*f.width+dx] >= 0 {
+				continue
+			}
+			for x, y := dx, dy; x < f.width && y < f.height; x, y = x+dx, y+dy {
+				quadrant[y*f.width+x] = int32(bases)
+			}
+			bases++
+		}
+	}
+	// Every LineOfSight identifier is its base identifier along with the sign
+	// of its coordinates.
+	f.count = 4 * bases
+	f.lines = make([]int32, (2*f.width-1)*(2*f.height-1))
+	for dy := 1 - f.height; dy < f.height; dy++ {
+		for dx := 1 - f.width; dx < f.width; dx++ {
+			id := 4 * quadrant[abs(dy)*f.width+abs(dx)]
+			if dx < 0 {
+				id |= 1
+			}
+			if dy < 0 {
+				id |= 2
+			}
+			f.lines[f.line(dx, dy)] = id
+		}
+	}
+	return f
+}
+
+// line returns the index of the displacement (dx, dy) in the lines table.
+func (f *Field) line(dx, dy int) int {
+	return (dy+f.height-1)*(2*f.width-1) + dx + f.width - 1
+}
+
+// detect returns the count of others asteroid in direct line of sight from
+// the i-th asteroid of the Field, see Asteroid.Detect. seen is a scratch
+// array of count elements and stamp a value it does not contain yet.
+func (f *Field) detect(i int, seen []int32, stamp int32) int {
+	a := f.asteroids[i]
+	n := 0
+	for _, o := range f.asteroids {
+		if a == o {
+			continue
+		}
+		if id := f.lines[f.line(o.x-a.x, o.y-a.y)]; seen[id] != stamp {
+			seen[id] = stamp
+			n++
+		}
+	}
+	return n
+}
+
+// DetectAll returns the count of others asteroid in direct line of sight from
+// every asteroid of the Field, in order. The result is identical to calling
+// Detect on each of them. The stations are dispatched to workers goroutines,
+// each having its own scratch array. When workers is zero,
+// runtime.GOMAXPROCS(0) is used.
+func (f *Field) DetectAll(workers int) []int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	detected := make([]int, len(f.asteroids))
+	var mu sync.Mutex
+	next := 0 // index of the next station
+	// take returns the index of the next station and true, or false when
+	// there is none left.
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(f.asteroids) {
+			return 0, false
+		}
+		i := next
+		next++
+		return i, true
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			seen := make([]int32, f.count)
+			// A new stamp per station avoid clearing seen, zero meaning not
+			// seen yet.
+			for stamp := int32(1); ; stamp++ {
+				i, ok := take()
+				if !ok {
+					return
+				}
+				detected[i] = f.detect(i, seen, stamp)
+			}
+		}()
+	}
+	wg.Wait()
+	return detected
+}
+
+// BestLocation find the asteroid of the Field which would be the best place to
+// build a new monitoring station, see the package level BestLocation.
+func (f *Field) BestLocation(workers int) (Asteroid, int, error) {
+	if len(f.asteroids) == 0 {
+		return Asteroid{}, 0, errors.New("empty slice argument")
+	}
+	loc, max := f.asteroids[0], -1
+	for i, n := range f.DetectAll(workers) {
+		if n > max {
+			loc, max = f.asteroids[i], n
+		}
+	}
+	return loc, max, nil
+}
+
+// min returns the smallest of a and b.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// max returns the largest of a and b.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
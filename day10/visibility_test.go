@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// synthetic returns the asteroids of a generated width by height map where
+// each position hold an asteroid with the given probability.
+func synthetic(width, height int, density float64, seed int64) []Asteroid {
+	rng := rand.New(rand.NewSource(seed))
+	var asteroids []Asteroid
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if rng.Float64() < density {
+				asteroids = append(asteroids, Asteroid{x, y})
+			}
+		}
+	}
+	return asteroids
+}
+
+func TestFieldDetectAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		asteroids []Asteroid
+	}{
+		{"empty", nil},
+		{"single", []Asteroid{{3, 7}}},
+		{"duplicates", []Asteroid{{1, 1}, {1, 1}, {2, 2}, {3, 3}, {1, 0}}},
+		{"offset", []Asteroid{{-5, 10}, {-3, 14}, {-1, 18}, {7, -2}}},
+		{"dense", synthetic(20, 20, 0.5, 1)},
+		{"sparse", synthetic(60, 40, 0.05, 2)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := NewField(tc.asteroids)
+			for _, workers := range []int{1, 3} {
+				got := f.DetectAll(workers)
+				if len(got) != len(tc.asteroids) {
+					t.Fatalf("DetectAll(%d) got %d counts; want %d", workers, len(got), len(tc.asteroids))
+				}
+				for i, a := range tc.asteroids {
+					if want := a.Detect(tc.asteroids); got[i] != want {
+						t.Errorf("DetectAll(%d)[%d] = %d; want %d", workers, i, got[i], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFieldBestLocation(t *testing.T) {
+	if _, _, err := NewField(nil).BestLocation(0); err == nil {
+		t.Error("BestLocation() on an empty field succeeded; want an error")
+	}
+	asteroids := synthetic(30, 30, 0.3, 3)
+	// the reference implementation, returning the first best asteroid.
+	var best Asteroid
+	max := -1
+	for _, a := range asteroids {
+		if n := a.Detect(asteroids); n > max {
+			best, max = a, n
+		}
+	}
+	a, n, err := NewField(asteroids).BestLocation(0)
+	if err != nil {
+		t.Fatalf("BestLocation() error: %s", err)
+	}
+	if a != best || n != max {
+		t.Errorf("BestLocation() = %v, %d; want %v, %d", a, n, best, max)
+	}
+}
+
+func BenchmarkBestLocation(b *testing.B) {
+	maps := []struct {
+		width, height int
+		density       float64
+	}{
+		{100, 100, 0.2},
+		{300, 300, 0.05},
+		{1000, 1000, 0.002},
+	}
+	for _, m := range maps {
+		asteroids := synthetic(m.width, m.height, m.density, 1)
+		name := fmt.Sprintf("%dx%d/%d", m.width, m.height, len(asteroids))
+		b.Run(name+"/Detect", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, a := range asteroids {
+					a.Detect(asteroids)
+				}
+			}
+		})
+		b.Run(name+"/Field", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewField(asteroids).BestLocation(0)
+			}
+		})
+		b.Run(name+"/Field/serial", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewField(asteroids).BestLocation(1)
+			}
+		})
+	}
+}
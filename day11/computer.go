@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 )
@@ -58,106 +59,200 @@ type Computer struct {
 	output chan Intcode
 	pc     int // instruction pointer
 	rbo    int // relative base offset
+	steps  int // count of instructions executed
 }
 
-// ErrAbordedExecution is returned by Execute when the computer's input channel
-// was close when a Read instruction was reached.
-var ErrAbordedExecution = errors.New("execution aborded")
+var (
+	// ErrAbordedExecution is returned by Execute when the computer's input
+	// channel was close when a Read instruction was reached.
+	ErrAbordedExecution = errors.New("execution aborded")
+	// ErrUnsupportedOpcode is returned by Execute when the instruction
+	// pointer reach an unknown operation code.
+	ErrUnsupportedOpcode = errors.New("unsupported opcode")
+	// ErrInvalidMode is returned by Execute when a parameter mode is unknown
+	// or not allowed for the parameter.
+	ErrInvalidMode = errors.New("invalid mode")
+)
+
+// AddressError is the error returned when a memory access is out of bounds.
+type AddressError struct {
+	Addr  int  // the faulty address
+	Write bool // true for a memory write, false for a memory read
+}
+
+// Error implements the error interface for AddressError.
+func (e *AddressError) Error() string {
+	if e.Write {
+		return fmt.Sprintf("invalid memory write at %d", e.Addr)
+	}
+	return fmt.Sprintf("invalid memory read at %d", e.Addr)
+}
+
+// ExecutionError is the error returned by Execute when the program could not
+// run to completion. It wraps the underlying error (i.e. one of the Err*
+// variables or an *AddressError) along with the Computer's state at the time
+// the faulty instruction was executed.
+type ExecutionError struct {
+	Err    error     // the underlying error
+	PC     int       // instruction pointer
+	Word   Intcode   // raw instruction at PC
+	Opcode Opcode    // operation code decoded from Word
+	Modes  [3]Mode   // parameters modes decoded from Word
+	RBO    int       // relative base offset
+	Steps  int       // count of instructions executed before the failure
+	Memory []Intcode // copy of the memory at the time of the failure
+}
 
-// Execute run the Intcode program on the Computer. It returns an error on
-// failure.
+// Error implements the error interface for ExecutionError.
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("%s (pc=%d instruction=%d rbo=%d steps=%d)",
+		e.Err, e.PC, e.Word, e.RBO, e.Steps)
+}
+
+// Unwrap returns the underlying error.
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// Dump returns a human readable report of the failure, displaying the
+// registers and the disassembly around the faulty instruction.
+func (e *ExecutionError) Dump() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n", e.Err)
+	fmt.Fprintf(&buf, "  pc=%d rbo=%d steps=%d\n", e.PC, e.RBO, e.Steps)
+	fmt.Fprintf(&buf, "  instruction=%d opcode=%v modes=%v\n", e.Word, e.Opcode, e.Modes)
+	buf.WriteString(Disassembly(e.Memory, e.PC, 5, 5))
+	return buf.String()
+}
+
+// Execute run the Intcode program on the Computer. It returns an
+// *ExecutionError on failure.
 func (c *Computer) Execute(program []Intcode) error {
 	// Setup the initial memory and registers
 	c.mem = make([]Intcode, len(program))
 	copy(c.mem, program)
 	c.pc = 0
 	c.rbo = 0
+	c.steps = 0
 	for {
-		opcode, m1, m2, m3, err := c.instruction()
+		halted, err := c.step()
+		switch {
+		case err != nil:
+			return c.fault(err)
+		case halted:
+			return nil
+		}
+		c.steps++
+	}
+}
+
+// fault wraps err into an *ExecutionError capturing the Computer's state.
+func (c *Computer) fault(err error) *ExecutionError {
+	e := &ExecutionError{
+		Err:    err,
+		PC:     c.pc,
+		RBO:    c.rbo,
+		Steps:  c.steps,
+		Memory: make([]Intcode, len(c.mem)),
+	}
+	// copy the memory so that the error is not altered by a later run.
+	copy(e.Memory, c.mem)
+	if c.pc >= 0 && c.pc < len(c.mem) {
+		e.Word = c.mem[c.pc]
+		e.Opcode, e.Modes[0], e.Modes[1], e.Modes[2] = decode(e.Word)
+	}
+	return e
+}
+
+// step execute the instruction at the instruction pointer. It returns true
+// once the Halt instruction is reached and any error encountered. On error,
+// the registers are left untouched.
+func (c *Computer) step() (bool, error) {
+	opcode, m1, m2, m3, err := c.instruction()
+	if err != nil {
+		return false, err
+	}
+	switch opcode {
+	case Add, Mult, LessThan, Equals: // binary operators
+		lhs, err := c.load(m1, c.pc+1)
+		if err != nil {
+			return false, err
+		}
+		rhs, err := c.load(m2, c.pc+2)
 		if err != nil {
-			return err
+			return false, err
 		}
+		var result Intcode
 		switch opcode {
-		case Add, Mult, LessThan, Equals: // binary operators
-			lhs, err := c.load(m1, c.pc+1)
-			if err != nil {
-				return err
-			}
-			rhs, err := c.load(m2, c.pc+2)
-			if err != nil {
-				return err
-			}
-			var result Intcode
-			switch opcode {
-			case Add:
-				result = lhs + rhs
-			case Mult:
-				result = lhs * rhs
-			case LessThan:
-				if lhs < rhs {
-					result = 1
-				}
-			case Equals:
-				if lhs == rhs {
-					result = 1
-				}
-			}
-			_, err = c.store(m3, c.pc+3, result)
-			if err != nil {
-				return err
+		case Add:
+			result = lhs + rhs
+		case Mult:
+			result = lhs * rhs
+		case LessThan:
+			if lhs < rhs {
+				result = 1
 			}
-			c.pc += 4
-		case JumpIfTrue, JumpIfFalse: // jumps opcodes
-			cond, err := c.load(m1, c.pc+1)
-			if err != nil {
-				return err
+		case Equals:
+			if lhs == rhs {
+				result = 1
 			}
-			addr, err := c.load(m2, c.pc+2)
-			if err != nil {
-				return err
-			}
-			var jump bool
-			switch opcode {
-			case JumpIfTrue:
-				jump = cond != 0
-			case JumpIfFalse:
-				jump = cond == 0
-			}
-			if jump {
-				c.pc = int(addr)
-			} else {
-				c.pc += 3
-			}
-		case RelativeBaseOffset:
-			off, err := c.load(m1, c.pc+1)
-			if err != nil {
-				return err
-			}
-			c.rbo += int(off)
-			c.pc += 2
-		case Read:
-			r, ok := <-c.input
-			if !ok {
-				return ErrAbordedExecution
-			}
-			_, err = c.store(m1, c.pc+1, r)
-			if err != nil {
-				return err
-			}
-			c.pc += 2
-		case Write:
-			code, err := c.load(m1, c.pc+1)
-			if err != nil {
-				return err
-			}
-			c.output <- code
-			c.pc += 2
-		case Halt:
-			return nil
-		default:
-			return fmt.Errorf("unsupported opcode: %d", opcode)
 		}
+		_, err = c.store(m3, c.pc+3, result)
+		if err != nil {
+			return false, err
+		}
+		c.pc += 4
+	case JumpIfTrue, JumpIfFalse: // jumps opcodes
+		cond, err := c.load(m1, c.pc+1)
+		if err != nil {
+			return false, err
+		}
+		addr, err := c.load(m2, c.pc+2)
+		if err != nil {
+			return false, err
+		}
+		var jump bool
+		switch opcode {
+		case JumpIfTrue:
+			jump = cond != 0
+		case JumpIfFalse:
+			jump = cond == 0
+		}
+		if jump {
+			c.pc = int(addr)
+		} else {
+			c.pc += 3
+		}
+	case RelativeBaseOffset:
+		off, err := c.load(m1, c.pc+1)
+		if err != nil {
+			return false, err
+		}
+		c.rbo += int(off)
+		c.pc += 2
+	case Read:
+		r, ok := <-c.input
+		if !ok {
+			return false, ErrAbordedExecution
+		}
+		_, err = c.store(m1, c.pc+1, r)
+		if err != nil {
+			return false, err
+		}
+		c.pc += 2
+	case Write:
+		code, err := c.load(m1, c.pc+1)
+		if err != nil {
+			return false, err
+		}
+		c.output <- code
+		c.pc += 2
+	case Halt:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: %d", ErrUnsupportedOpcode, opcode)
 	}
+	return false, nil
 }
 
 // expand the Computer's memory with zero values up to i. Once it returns, it
@@ -175,7 +270,7 @@ func (c *Computer) expand(i int) {
 // the value read and an error when the address is invalid.
 func (c *Computer) fetch(i int) (Intcode, error) {
 	if i < 0 {
-		return 0, fmt.Errorf("invalid memory read at %d", i)
+		return 0, &AddressError{Addr: i}
 	}
 	c.expand(i)
 	return c.mem[i], nil
@@ -185,7 +280,7 @@ func (c *Computer) fetch(i int) (Intcode, error) {
 // returns the value written and an error when the address is invalid.
 func (c *Computer) put(i int, val Intcode) (Intcode, error) {
 	if i < 0 {
-		return 0, fmt.Errorf("invalid memory write at %d", i)
+		return 0, &AddressError{Addr: i, Write: true}
 	}
 	c.expand(i)
 	c.mem[i] = val
@@ -207,7 +302,7 @@ func (c *Computer) load(mode Mode, i int) (Intcode, error) {
 	case Relative:
 		return c.fetch(c.rbo + int(param))
 	default:
-		return 0, fmt.Errorf("invalid mode: %v", mode)
+		return 0, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
 	}
 }
 
@@ -226,7 +321,7 @@ func (c *Computer) store(mode Mode, i int, val Intcode) (Intcode, error) {
 	default:
 		// NOTE from day05: Parameters that an instruction writes to will
 		// never be in immediate mode.
-		return 0, fmt.Errorf("invalid mode %v", mode)
+		return 0, fmt.Errorf("%w: %v", ErrInvalidMode, mode)
 	}
 }
 
@@ -238,11 +333,18 @@ func (c *Computer) instruction() (Opcode, Mode, Mode, Mode, error) {
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
+	opcode, m1, m2, m3 := decode(i)
+	return opcode, m1, m2, m3, nil
+}
+
+// decode returns the operation code, mode of the first parameter, mode of the
+// second parameter and mode of the third parameter of the given instruction.
+func decode(i Intcode) (Opcode, Mode, Mode, Mode) {
 	opcode := Opcode(i % 100)
 	m1 := Mode(i / 100 % 10)
 	m2 := Mode(i / 1000 % 10)
 	m3 := Mode(i / 10000 % 10)
-	return opcode, m1, m2, m3, nil
+	return opcode, m1, m2, m3
 }
 
 // nextPow2 returns the smallest power of two greater or equal to n.
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecuteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []Intcode
+		want    error
+		pc      int
+		steps   int
+	}{
+		{
+			name:    "unsupported opcode",
+			program: []Intcode{1101, 1, 2, 5, 42, 0},
+			want:    ErrUnsupportedOpcode,
+			pc:      4,
+			steps:   1,
+		},
+		{
+			name:    "immediate mode store",
+			program: []Intcode{109, 3, 11101, 1, 2, 3, Halt},
+			want:    ErrInvalidMode,
+			pc:      2,
+			steps:   1,
+		},
+		{
+			name:    "negative address read",
+			program: []Intcode{1, -1, 0, 0, Halt},
+			want:    &AddressError{Addr: -1},
+			pc:      0,
+			steps:   0,
+		},
+		{
+			name:    "aborted read",
+			program: []Intcode{104, 1, 3, 0, Halt},
+			want:    ErrAbordedExecution,
+			pc:      2,
+			steps:   1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Computer{
+				input:  make(chan Intcode),
+				output: make(chan Intcode, len(tc.program)),
+			}
+			close(c.input)
+			err := c.Execute(tc.program)
+			var xerr *ExecutionError
+			if !errors.As(err, &xerr) {
+				t.Fatalf("Execute() error = %v; want an *ExecutionError", err)
+			}
+			var aerr *AddressError
+			switch want := tc.want.(type) {
+			case *AddressError:
+				if !errors.As(err, &aerr) || *aerr != *want {
+					t.Errorf("Execute() error = %v; want %v", err, want)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("Execute() error = %v; want %v", err, want)
+				}
+			}
+			if xerr.PC != tc.pc {
+				t.Errorf("pc = %d; want %d", xerr.PC, tc.pc)
+			}
+			if xerr.Word != tc.program[tc.pc] {
+				t.Errorf("instruction = %d; want %d", xerr.Word, tc.program[tc.pc])
+			}
+			if xerr.Steps != tc.steps {
+				t.Errorf("steps = %d; want %d", xerr.Steps, tc.steps)
+			}
+			if c.mem[0]++; xerr.Memory[0] == c.mem[0] {
+				t.Errorf("Memory is altered by the computer; want a copy")
+			}
+		})
+	}
+}
+
+func TestDisassembly(t *testing.T) {
+	program := []Intcode{1101, 1, 2, 9, 204, -1, 42, Halt, 0, 0}
+	want := strings.Join([]string{
+		"   0000: 1101,1,2,9               Add 1, 2, [9]",
+		"   0004: 204,-1                   Write rbo[-1]",
+		"=> 0006: 42                       (data)",
+		"   0007: 99                       Halt",
+		"   0008: 0                        (data)",
+		"",
+	}, "\n")
+	if got := Disassembly(program, 6, 5, 2); got != want {
+		t.Errorf("Disassembly() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Instruction is a decoded Intcode instruction.
+type Instruction struct {
+	Addr   int       // address of the instruction in memory
+	Opcode Opcode    // operation code
+	Modes  []Mode    // mode of each parameter
+	Params []Intcode // raw parameters
+}
+
+// String implements Stringer for Opcode.
+func (op Opcode) String() string {
+	switch op {
+	case Add:
+		return "Add"
+	case Mult:
+		return "Mult"
+	case Read:
+		return "Read"
+	case Write:
+		return "Write"
+	case JumpIfTrue:
+		return "JumpIfTrue"
+	case JumpIfFalse:
+		return "JumpIfFalse"
+	case LessThan:
+		return "LessThan"
+	case Equals:
+		return "Equals"
+	case RelativeBaseOffset:
+		return "RelativeBaseOffset"
+	case Halt:
+		return "Halt"
+	default:
+		return fmt.Sprintf("Opcode(%d)", uint8(op))
+	}
+}
+
+// Arity returns the count of parameters of the operation along with true when
+// op is a supported operation code, false otherwise.
+func (op Opcode) Arity() (int, bool) {
+	switch op {
+	case Add, Mult, LessThan, Equals:
+		return 3, true
+	case JumpIfTrue, JumpIfFalse:
+		return 2, true
+	case Read, Write, RelativeBaseOffset:
+		return 1, true
+	case Halt:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// Decode the instruction at the given address in mem. It returns the decoded
+// Instruction and true on success, false when addr does not hold a supported
+// operation code or its parameters are out of mem.
+func Decode(mem []Intcode, addr int) (Instruction, bool) {
+	var in Instruction
+	if addr < 0 || addr >= len(mem) {
+		return in, false
+	}
+	opcode, m1, m2, m3 := decode(mem[addr])
+	n, ok := opcode.Arity()
+	if !ok || addr+n >= len(mem) {
+		return in, false
+	}
+	in = Instruction{
+		Addr:   addr,
+		Opcode: opcode,
+		Modes:  []Mode{m1, m2, m3}[:n],
+		Params: mem[addr+1 : addr+1+n],
+	}
+	return in, true
+}
+
+// Size returns the count of Intcode used by the instruction in memory.
+func (in Instruction) Size() int {
+	return 1 + len(in.Params)
+}
+
+// String implements Stringer for Instruction. Parameters are displayed as
+// [addr] in Position mode, as is in Immediate mode and as rbo[off] in
+// Relative mode.
+func (in Instruction) String() string {
+	params := make([]string, len(in.Params))
+	for i, p := range in.Params {
+		switch in.Modes[i] {
+		case Position:
+			params[i] = fmt.Sprintf("[%d]", p)
+		case Immediate:
+			params[i] = fmt.Sprintf("%d", p)
+		case Relative:
+			params[i] = fmt.Sprintf("rbo[%d]", p)
+		default:
+			params[i] = fmt.Sprintf("?%d", p)
+		}
+	}
+	if len(params) == 0 {
+		return in.Opcode.String()
+	}
+	return in.Opcode.String() + " " + strings.Join(params, ", ")
+}
+
+// Disassemble walk the instructions of mem from its start. At each address,
+// fn is called with the Instruction decoded there and true, or false when the
+// address does not decode into an instruction. fn returns whether the
+// address is code, the walk then resuming after the Instruction, or data, the
+// walk resuming at the next address, along with whether the walk should go
+// on. Because instructions and data can be mixed up in memory, fn decide which
+// decoded instructions are code.
+func Disassemble(mem []Intcode, fn func(addr int, in Instruction, ok bool) (code, more bool)) {
+	for addr := 0; addr < len(mem); {
+		in, ok := Decode(mem, addr)
+		code, more := fn(addr, in, ok)
+		if ok && code {
+			addr += in.Size()
+		} else {
+			addr++
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// Disassembly returns a listing of the instructions in mem around pc, with up
+// to before instructions preceding it and after instructions following it.
+// The instruction at pc is marked with a leading arrow. Because instructions
+// and data can be mixed up in memory, the listing is a best effort: every
+// address not decoding into an instruction is displayed as data.
+func Disassembly(mem []Intcode, pc, before, after int) string {
+	type line struct {
+		addr  int
+		words []Intcode
+		text  string
+	}
+	if pc < 0 || pc >= len(mem) {
+		return fmt.Sprintf("  pc=%d is out of the memory bounds [0, %d)\n", pc, len(mem))
+	}
+	// disassemble from the start of the memory up to pc, in order to find the
+	// instruction boundaries preceding pc.
+	var lines []line
+	var marked, n int
+	Disassemble(mem, func(addr int, in Instruction, ok bool) (bool, bool) {
+		if addr == pc {
+			marked = len(lines)
+		}
+		// an instruction overlapping pc is data.
+		code := ok && (addr >= pc || addr+in.Size() <= pc)
+		size, text := 1, "(data)"
+		if code {
+			size, text = in.Size(), in.String()
+		}
+		lines = append(lines, line{addr, mem[addr : addr+size], text})
+		if addr+size > pc {
+			n++
+		}
+		return code, n <= after
+	})
+	lo, hi := marked-before, marked+after+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(lines) {
+		hi = len(lines)
+	}
+	var buf bytes.Buffer
+	for i, l := range lines[lo:hi] {
+		arrow := "  "
+		if lo+i == marked {
+			arrow = "=>"
+		}
+		words := make([]string, len(l.words))
+		for j, w := range l.words {
+			words[j] = fmt.Sprintf("%d", w)
+		}
+		fmt.Fprintf(&buf, "%s %04d: %-24s %s\n", arrow, l.addr, strings.Join(words, ","), l.text)
+	}
+	return buf.String()
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strconv"
+
+	"github.com/kaworu/adventofcode-2019/ocr"
 )
 
 // Headings
@@ -259,7 +262,7 @@ func main() {
 	robot := NewRobot()
 	err = robot.Paint(ship, program)
 	if err != nil {
-		log.Fatalf("painting error: %s\n", err)
+		fatal(err)
 	}
 	fmt.Printf("%d panels were paint at least once,\n", ship.PaintedPanelCount())
 
@@ -268,9 +271,24 @@ func main() {
 	ship.panels[PointOfOrigin()] = White
 	err = robot.Paint(ship, program)
 	if err != nil {
-		log.Fatalf("painting error: %s\n", err)
+		fatal(err)
 	}
 	fmt.Printf("and here is your ship after the robot started on a white panel:\n%v", ship)
+	text, err := ocr.Recognize(ocr.Parse(ship.String(), '#'))
+	if err != nil {
+		log.Fatalf("Recognize(): %s\n", err)
+	}
+	fmt.Printf("The registration identifier painted is %s.\n", text)
+}
+
+// fatal log the given painting error and exit. When err happened during the
+// brain execution, the failure report is included.
+func fatal(err error) {
+	var xerr *ExecutionError
+	if errors.As(err, &xerr) {
+		log.Fatalf("painting error: %s", xerr.Dump())
+	}
+	log.Fatalf("painting error: %s\n", err)
 }
 
 // Parse an Intcode program.
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kaworu/adventofcode-2019/intcode/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	run := func(program, input []int64) ([]int64, []int64, error) {
+		prog := make([]Intcode, len(program))
+		for i, x := range program {
+			prog[i] = Intcode(x)
+		}
+		c := Computer{
+			input:  make(chan Intcode, len(input)),
+			output: make(chan Intcode),
+		}
+		for _, x := range input {
+			c.input <- Intcode(x)
+		}
+		close(c.input)
+		halt := make(chan error, 1)
+		go func() {
+			halt <- c.Execute(prog)
+			close(c.output)
+		}()
+		var output []int64
+		for x := range c.output {
+			output = append(output, int64(x))
+		}
+		if err := <-halt; err != nil {
+			return nil, nil, err
+		}
+		final := make([]int64, len(c.mem))
+		for i, x := range c.mem {
+			final[i] = int64(x)
+		}
+		return output, final, nil
+	}
+	conformance.Test(t, conformance.MachineFunc(run), conformance.Day09)
+}
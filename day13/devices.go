@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrReadOnly is returned by a Device when the program attempt to write at
+// a read-only offset.
+var ErrReadOnly = errors.New("read-only device register")
+
+// Timer is a Device reporting the elapsed time in milliseconds. It use a
+// single register at offset 0: reading it returns the milliseconds elapsed
+// since the Timer was started, writing it restarts the Timer.
+type Timer struct {
+	// Now returns the current time, time.Now is used when nil.
+	Now   func() time.Time
+	mu    sync.Mutex
+	start time.Time
+}
+
+// Random is a Device producing pseudo-random numbers. It use a single
+// register at offset 0: reading it returns a non-negative pseudo-random
+// Intcode, writing it seeds the generator.
+type Random struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// Framebuffer is a Device mapping every Tile of a fixed size Screen into
+// memory, the Tile at (x, y) being at the offset y * width + x.
+type Framebuffer struct {
+	width, height int
+	mu            sync.Mutex
+	tiles         []Tile
+}
+
+// Keyboard is a Device buffering the keys pressed until the program read
+// them. Reading at offset 0 pops the next key (zero when the buffer is empty),
+// reading at offset 1 returns the count of keys in the buffer. Writing at
+// offset 0 push back a key at the front of the buffer, so that it is the next
+// one read.
+type Keyboard struct {
+	mu   sync.Mutex
+	keys []Intcode
+}
+
+// NewTimer create a Timer started now.
+func NewTimer() *Timer {
+	t := &Timer{}
+	t.Reset()
+	return t
+}
+
+// now returns the current time according to the Timer's clock.
+func (t *Timer) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+// Reset restart the Timer.
+func (t *Timer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = t.now()
+}
+
+// Load implements Device for Timer.
+func (t *Timer) Load(off int) (Intcode, error) {
+	if off != 0 {
+		return 0, fmt.Errorf("invalid timer register %d", off)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Intcode(t.now().Sub(t.start).Milliseconds()), nil
+}
+
+// Store implements Device for Timer.
+func (t *Timer) Store(off int, val Intcode) error {
+	if off != 0 {
+		return fmt.Errorf("invalid timer register %d", off)
+	}
+	t.Reset()
+	return nil
+}
+
+// NewRandom create a Random Device seeded with the given value.
+func NewRandom(seed int64) *Random {
+	return &Random{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Load implements Device for Random.
+func (r *Random) Load(off int) (Intcode, error) {
+	if off != 0 {
+		return 0, fmt.Errorf("invalid random register %d", off)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Intcode(r.rng.Int63()), nil
+}
+
+// Store implements Device for Random.
+func (r *Random) Store(off int, val Intcode) error {
+	if off != 0 {
+		return fmt.Errorf("invalid random register %d", off)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rng.Seed(int64(val))
+	return nil
+}
+
+// NewFramebuffer create a Framebuffer of the given dimensions with all its
+// tiles Empty. It should be mapped with a size of width * height.
+func NewFramebuffer(width, height int) *Framebuffer {
+	return &Framebuffer{
+		width:  width,
+		height: height,
+		tiles:  make([]Tile, width*height),
+	}
+}
+
+// Size returns the count of tiles in the Framebuffer.
+func (fb *Framebuffer) Size() int {
+	return fb.width * fb.height
+}
+
+// Load implements Device for Framebuffer.
+func (fb *Framebuffer) Load(off int) (Intcode, error) {
+	if off < 0 || off >= fb.Size() {
+		return 0, fmt.Errorf("framebuffer offset %d out of bounds", off)
+	}
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return Intcode(fb.tiles[off]), nil
+}
+
+// Store implements Device for Framebuffer.
+func (fb *Framebuffer) Store(off int, val Intcode) error {
+	if off < 0 || off >= fb.Size() {
+		return fmt.Errorf("framebuffer offset %d out of bounds", off)
+	}
+	tid, ok := NewTile(val)
+	if !ok {
+		return fmt.Errorf("%d: invalid tile id", val)
+	}
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	fb.tiles[off] = tid
+	return nil
+}
+
+// Screen returns the Framebuffer's content. Empty tiles are omitted.
+func (fb *Framebuffer) Screen() Screen {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	screen := make(Screen)
+	for i, t := range fb.tiles {
+		if t != Empty {
+			p := Point{x: int64(i % fb.width), y: int64(i / fb.width)}
+			screen[p] = t
+		}
+	}
+	return screen
+}
+
+// Press push the given keys at the end of the Keyboard's buffer.
+func (kb *Keyboard) Press(keys ...Intcode) {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	kb.keys = append(kb.keys, keys...)
+}
+
+// Load implements Device for Keyboard.
+func (kb *Keyboard) Load(off int) (Intcode, error) {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	switch off {
+	case 0:
+		if len(kb.keys) == 0 {
+			return 0, nil
+		}
+		key := kb.keys[0]
+		kb.keys = kb.keys[1:]
+		return key, nil
+	case 1:
+		return Intcode(len(kb.keys)), nil
+	default:
+		return 0, fmt.Errorf("invalid keyboard register %d", off)
+	}
+}
+
+// Store implements Device for Keyboard.
+func (kb *Keyboard) Store(off int, val Intcode) error {
+	switch off {
+	case 0:
+		kb.mu.Lock()
+		defer kb.mu.Unlock()
+		kb.keys = append([]Intcode{val}, kb.keys...)
+		return nil
+	case 1:
+		return ErrReadOnly
+	default:
+		return fmt.Errorf("invalid keyboard register %d", off)
+	}
+}
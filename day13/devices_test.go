@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	c := Computer{
+		input:  make(chan Intcode),
+		output: make(chan Intcode, 1),
+	}
+	close(c.input)
+	fb := NewFramebuffer(2, 2)
+	if err := c.Map(1000, fb.Size(), fb); err != nil {
+		t.Fatalf("Map(framebuffer) error: %s", err)
+	}
+	var kb Keyboard
+	if err := c.Map(2000, 2, &kb); err != nil {
+		t.Fatalf("Map(keyboard) error: %s", err)
+	}
+	if err := c.Map(1003, 10, NewRandom(0)); err == nil {
+		t.Errorf("Map() on an overlapping range succeeded")
+	}
+
+	kb.Press(Intcode(Wall), Intcode(Ball), 7)
+	program := []Intcode{
+		1001, 2000, 0, 1000, // framebuffer(0, 0) = keyboard
+		1001, 2000, 0, 1003, // framebuffer(1, 1) = keyboard
+		4, 2001, // Write the count of keys left
+		Halt,
+	}
+	if err := c.Execute(program); err != nil {
+		t.Fatalf("Execute() error: %s", err)
+	}
+	if n := <-c.output; n != 1 {
+		t.Errorf("keys left = %d; want 1", n)
+	}
+	screen := fb.Screen()
+	if len(screen) != 2 || screen[Point{0, 0}] != Wall || screen[Point{1, 1}] != Ball {
+		t.Errorf("Screen() = %v; want Wall at 0,0 and Ball at 1,1", screen)
+	}
+
+	// the last key is not a valid tile.
+	err := c.Execute(program[:4])
+	if err == nil {
+		t.Errorf("Execute() storing an invalid tile succeeded")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	a, b := NewRandom(42), NewRandom(42)
+	for i := 0; i < 10; i++ {
+		x, _ := a.Load(0)
+		y, _ := b.Load(0)
+		if x != y || x < 0 {
+			t.Fatalf("Load() = %d and %d; want the same non-negative value", x, y)
+		}
+	}
+	_ = a.Store(0, 7)
+	_ = b.Store(0, 7)
+	x, _ := a.Load(0)
+	y, _ := b.Load(0)
+	if x != y {
+		t.Errorf("Load() after seeding = %d and %d; want the same value", x, y)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	now := time.Unix(0, 0)
+	timer := &Timer{Now: func() time.Time { return now }}
+	timer.Reset()
+	now = now.Add(1500 * time.Millisecond)
+	if ms, _ := timer.Load(0); ms != 1500 {
+		t.Errorf("Load() = %d; want 1500", ms)
+	}
+	_ = timer.Store(0, 0)
+	if ms, _ := timer.Load(0); ms != 0 {
+		t.Errorf("Load() after Store() = %d; want 0", ms)
+	}
+}
+
+func TestKeyboard(t *testing.T) {
+	var kb Keyboard
+	kb.Press(1, 2)
+	if err := kb.Store(0, 3); err != nil {
+		t.Fatalf("Store() error: %s", err)
+	}
+	for _, want := range []Intcode{3, 1, 2, 0} {
+		if key, err := kb.Load(0); err != nil || key != want {
+			t.Errorf("Load() = %d, %v; want %d", key, err, want)
+		}
+	}
+	if err := kb.Store(1, 0); err != ErrReadOnly {
+		t.Errorf("Store() error = %v; want %v", err, ErrReadOnly)
+	}
+}
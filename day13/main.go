@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -105,16 +106,61 @@ func draw(program []Intcode) (Screen, error) {
 	}
 }
 
+// drawFramebuffer execute the given program with fb mapped into its memory at
+// addr, and return the Screen drawn into fb once it is executed along with any
+// error encountered. The program's output is discarded.
+func drawFramebuffer(program []Intcode, addr int, fb *Framebuffer) (Screen, error) {
+	c := Computer{
+		input:  make(chan Intcode),
+		output: make(chan Intcode),
+	}
+	close(c.input)
+	if err := c.Map(addr, fb.Size(), fb); err != nil {
+		return nil, err
+	}
+
+	halt := make(chan error)
+	go func() {
+		halt <- c.Execute(program)
+		close(halt)
+	}()
+
+	for {
+		select {
+		case err := <-halt:
+			if err != nil {
+				return nil, err
+			}
+			return fb.Screen(), nil
+		case <-c.output:
+		}
+	}
+}
+
 // main compute and display the count of block tiles on the screen when the
 // game exits.
 func main() {
+	addr := flag.Int("framebuffer", 0, "map a framebuffer at `address` and read the screen from it instead of the output, disabled when 0")
+	width := flag.Int("width", 64, "the framebuffer width in tiles")
+	height := flag.Int("height", 32, "the framebuffer height in tiles")
+	flag.Parse()
+
 	program, err := Parse(os.Stdin)
 	if err != nil {
 		log.Fatalf("input error: %s\n", err)
 	}
 
-	screen, err := draw(program)
+	var screen Screen
+	if *addr > 0 {
+		screen, err = drawFramebuffer(program, *addr, NewFramebuffer(*width, *height))
+	} else {
+		screen, err = draw(program)
+	}
 	if err != nil {
+		var xerr *ExecutionError
+		if errors.As(err, &xerr) {
+			log.Fatalf("execution error: %s", xerr.Dump())
+		}
 		log.Fatal(err)
 	}
 
@@ -2,6 +2,27 @@ package main
 
 import "testing"
 
+func TestDrawFramebuffer(t *testing.T) {
+	// This program draw a Block at 1,0 and a Ball at 0,1 into the
+	// framebuffer mapped at 100, and output 7.
+	program := []Intcode{
+		1101, 0, 2, 101,
+		1101, 0, 4, 102,
+		104, 7,
+		Halt,
+	}
+	screen, err := drawFramebuffer(program, 100, NewFramebuffer(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(screen) != 2 || screen[Point{1, 0}] != Block || screen[Point{0, 1}] != Ball {
+		t.Errorf("got %v; want a Block at 1,0 and a Ball at 0,1", screen)
+	}
+	if _, err := drawFramebuffer([]Intcode{1101, 0, 9, 100, Halt}, 100, NewFramebuffer(2, 2)); err == nil {
+		t.Errorf("expected an error when drawing an invalid tile")
+	}
+}
+
 func TestDraw(t *testing.T) {
 	// This program simply output the sequence 1,2,3,6,5,4
 	program := []Intcode{
@@ -0,0 +1,308 @@
+// Package conformance implements a suite of Intcode programs along with their
+// expected input, output and final memory. Any Intcode computer
+// implementation can be checked against every known program by calling Test
+// from its own tests.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Feature is a set of Intcode capabilities.
+type Feature uint
+
+// Features
+const (
+	// Arithmetic is the support of the Add, Mult and Halt instructions in
+	// position mode, as introduced by day02.
+	Arithmetic Feature = 1 << iota
+	// IO is the support of the Read and Write instructions, as introduced by
+	// day05.
+	IO
+	// Immediate is the support of the immediate parameter mode, as
+	// introduced by day05.
+	Immediate
+	// Comparisons is the support of the JumpIfTrue, JumpIfFalse, LessThan and
+	// Equals instructions, as introduced by day05 part two.
+	Comparisons
+	// Relative is the support of the RelativeBaseOffset instruction and the
+	// relative parameter mode, as introduced by day09.
+	Relative
+	// LargeMemory is the support of memory access beyond the program, as
+	// introduced by day09.
+	LargeMemory
+	// LargeNumbers is the support of 64-bit Intcode, as introduced by day09.
+	LargeNumbers
+
+	// Day02 are the features of the day02 Intcode computer.
+	Day02 = Arithmetic
+	// Day05 are the features of the day05 Intcode computer.
+	Day05 = Day02 | IO | Immediate | Comparisons
+	// Day09 are the features of the complete Intcode computer.
+	Day09 = Day05 | Relative | LargeMemory | LargeNumbers
+)
+
+// Machine is an Intcode computer implementation.
+type Machine interface {
+	// Run execute the program with the given input. It returns the output
+	// and the memory once the program has halted, along with any error
+	// encountered. The program and input slices must not be modified.
+	Run(program, input []int64) (output, memory []int64, err error)
+}
+
+// MachineFunc is an adapter to allow the use of an ordinary function as a
+// Machine.
+type MachineFunc func(program, input []int64) (output, memory []int64, err error)
+
+// Case is a program along with its expected behaviour.
+type Case struct {
+	Name     string
+	Requires Feature // the features needed to run the program
+	Program  []int64
+	Input    []int64
+	// Output is the expected output, it is not checked when nil.
+	Output []int64
+	// Memory is the expected final memory, it is not checked when nil.
+	// Memory beyond its length is expected to be zero, so that computers
+	// expanding their memory are supported.
+	Memory []int64
+}
+
+// Cases are the known programs, mostly the examples from the puzzles.
+var Cases = []Case{
+	// day02
+	{
+		Name:     "day02 detailed example",
+		Requires: Day02,
+		Program:  []int64{1, 9, 10, 3, 2, 3, 11, 0, 99, 30, 40, 50},
+		Memory:   []int64{3500, 9, 10, 70, 2, 3, 11, 0, 99, 30, 40, 50},
+	},
+	{
+		Name:     "day02 1+1",
+		Requires: Day02,
+		Program:  []int64{1, 0, 0, 0, 99},
+		Memory:   []int64{2, 0, 0, 0, 99},
+	},
+	{
+		Name:     "day02 3*2",
+		Requires: Day02,
+		Program:  []int64{2, 3, 0, 3, 99},
+		Memory:   []int64{2, 3, 0, 6, 99},
+	},
+	{
+		Name:     "day02 99*99",
+		Requires: Day02,
+		Program:  []int64{2, 4, 4, 5, 99, 0},
+		Memory:   []int64{2, 4, 4, 5, 99, 9801},
+	},
+	{
+		Name:     "day02 self-modifying",
+		Requires: Day02,
+		Program:  []int64{1, 1, 1, 4, 99, 5, 6, 0, 99},
+		Memory:   []int64{30, 1, 1, 4, 2, 5, 6, 0, 99},
+	},
+	// day05
+	{
+		Name:     "day05 identity",
+		Requires: Arithmetic | IO,
+		Program:  []int64{3, 0, 4, 0, 99},
+		Input:    []int64{42},
+		Output:   []int64{42},
+		Memory:   []int64{42, 0, 4, 0, 99},
+	},
+	{
+		Name:     "day05 multiply then halt",
+		Requires: Arithmetic | Immediate,
+		Program:  []int64{1002, 4, 3, 4, 33},
+		Memory:   []int64{1002, 4, 3, 4, 99},
+	},
+	{
+		Name:     "day05 add negative immediate",
+		Requires: Arithmetic | Immediate,
+		Program:  []int64{1101, 100, -1, 4, 0},
+		Memory:   []int64{1101, 100, -1, 4, 99},
+	},
+	// day07
+	{
+		Name:     "day07 first example last amplifier",
+		Requires: Day05,
+		Program:  []int64{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 0, 0},
+		Input:    []int64{0, 4321},
+		Output:   []int64{43210},
+		Memory:   []int64{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 43210, 43210},
+	},
+	{
+		Name:     "day07 second example last amplifier",
+		Requires: Day05,
+		Program:  []int64{3, 23, 3, 24, 1002, 24, 10, 24, 1002, 23, -1, 23, 101, 5, 23, 23, 1, 24, 23, 23, 4, 23, 99, 0, 0},
+		Input:    []int64{4, 5432},
+		Output:   []int64{54321},
+	},
+	// day09
+	{
+		Name:     "day09 quine",
+		Requires: Day09,
+		Program:  []int64{109, 1, 204, -1, 1001, 100, 1, 100, 1008, 100, 16, 101, 1006, 101, 0, 99},
+		Output:   []int64{109, 1, 204, -1, 1001, 100, 1, 100, 1008, 100, 16, 101, 1006, 101, 0, 99},
+	},
+	{
+		Name:     "day09 16-digit output",
+		Requires: Day05 | LargeNumbers,
+		Program:  []int64{1102, 34915192, 34915192, 7, 4, 7, 99, 0},
+		Output:   []int64{1219070632396864},
+		Memory:   []int64{1102, 34915192, 34915192, 7, 4, 7, 99, 1219070632396864},
+	},
+	{
+		Name:     "day09 large number output",
+		Requires: Day05 | LargeNumbers,
+		Program:  []int64{104, 1125899906842624, 99},
+		Output:   []int64{1125899906842624},
+		Memory:   []int64{104, 1125899906842624, 99},
+	},
+}
+
+func init() {
+	// day05 comparisons and jumps programs take a single input and produce
+	// a single output, generate a case for some interesting inputs.
+	unary := []struct {
+		name    string
+		program []int64
+		f       func(int64) int64
+	}{
+		{
+			name:    "position mode input==8",
+			program: []int64{3, 9, 8, 9, 10, 9, 4, 9, 99, -1, 8},
+			f:       func(x int64) int64 { return b2i(x == 8) },
+		},
+		{
+			name:    "position mode input<8",
+			program: []int64{3, 9, 7, 9, 10, 9, 4, 9, 99, -1, 8},
+			f:       func(x int64) int64 { return b2i(x < 8) },
+		},
+		{
+			name:    "immediate mode input==8",
+			program: []int64{3, 3, 1108, -1, 8, 3, 4, 3, 99},
+			f:       func(x int64) int64 { return b2i(x == 8) },
+		},
+		{
+			name:    "immediate mode input<8",
+			program: []int64{3, 3, 1107, -1, 8, 3, 4, 3, 99},
+			f:       func(x int64) int64 { return b2i(x < 8) },
+		},
+		{
+			name:    "position mode jump input!=0",
+			program: []int64{3, 12, 6, 12, 15, 1, 13, 14, 13, 4, 13, 99, -1, 0, 1, 9},
+			f:       func(x int64) int64 { return b2i(x != 0) },
+		},
+		{
+			name:    "immediate mode jump input!=0",
+			program: []int64{3, 3, 1105, -1, 9, 1101, 0, 0, 12, 4, 12, 99, 1},
+			f:       func(x int64) int64 { return b2i(x != 0) },
+		},
+		{
+			name: "compare to 8",
+			program: []int64{
+				3, 21, 1008, 21, 8, 20, 1005, 20, 22, 107, 8, 21, 20, 1006, 20, 31,
+				1106, 0, 36, 98, 0, 0, 1002, 21, 125, 20, 4, 20, 1105, 1, 46, 104,
+				999, 1105, 1, 46, 1101, 1000, 1, 20, 4, 20, 1105, 1, 46, 98, 99,
+			},
+			f: func(x int64) int64 { return 1000 + b2i(x > 8) - b2i(x < 8) },
+		},
+	}
+	for _, u := range unary {
+		for _, x := range []int64{-1, 0, 7, 8, 9} {
+			Cases = append(Cases, Case{
+				Name:     fmt.Sprintf("day05 %s with %d", u.name, x),
+				Requires: Day05,
+				Program:  u.program,
+				Input:    []int64{x},
+				Output:   []int64{u.f(x)},
+			})
+		}
+	}
+}
+
+// Run implements Machine for MachineFunc.
+func (f MachineFunc) Run(program, input []int64) (output, memory []int64, err error) {
+	return f(program, input)
+}
+
+// Check run the Case's program on m. It returns an error describing the first
+// mismatch with the expected behaviour, nil when m behaved as expected.
+func (c Case) Check(m Machine) error {
+	program := make([]int64, len(c.Program))
+	copy(program, c.Program)
+	input := make([]int64, len(c.Input))
+	copy(input, c.Input)
+	output, memory, err := m.Run(program, input)
+	switch {
+	case err != nil:
+		return fmt.Errorf("execution error: %w", err)
+	case c.Output != nil && !equal(output, c.Output):
+		return fmt.Errorf("output = %v; want %v", output, c.Output)
+	case c.Memory != nil && !equalMemory(memory, c.Memory):
+		return fmt.Errorf("final memory = %v; want %v", memory, c.Memory)
+	}
+	return nil
+}
+
+// ErrUnsupported is the reason given by Test when skipping a Case requiring
+// features the Machine does not implement.
+var ErrUnsupported = errors.New("unsupported features")
+
+// Test check m against every Case requiring only the given features, reporting
+// the failures through t. Each Case is run as a subtest named after it, the
+// cases requiring more features are skipped.
+func Test(t *testing.T, m Machine, features Feature) {
+	t.Helper()
+	for _, c := range Cases {
+		c := c // capture c
+		t.Run(c.Name, func(t *testing.T) {
+			if c.Requires&^features != 0 {
+				t.Skip(ErrUnsupported)
+			}
+			if err := c.Check(m); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// equal tells whether xs and ys contain the same elements. A nil argument is
+// equivalent to an empty slice.
+func equal(xs, ys []int64) bool {
+	if len(xs) != len(ys) {
+		return false
+	}
+	for i := range xs {
+		if xs[i] != ys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalMemory tells whether the final memory mem match the expectation want,
+// mem being allowed to be longer than want as long as its extra elements are
+// zero.
+func equalMemory(mem, want []int64) bool {
+	if len(mem) < len(want) {
+		return false
+	}
+	for i := range mem {
+		if i < len(want) && mem[i] != want[i] || i >= len(want) && mem[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// b2i returns 1 when b is true, 0 otherwise.
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Coverage records how the memory of a Computer was used by the programs it
+// executed. Every map is indexed by memory address.
+type Coverage struct {
+	Executed map[int]int // count of executions as an instruction start
+	Reads    map[int]int // count of reads as data
+	Writes   map[int]int // count of writes as data
+	Taken    map[int]int // count of jumps taken by a conditional jump
+	NotTaken map[int]int // count of jumps not taken by a conditional jump
+}
+
+// coverKind classify an address range in a coverage report.
+type coverKind uint8
+
+const (
+	covered   coverKind = iota // executed instruction
+	uncovered                  // instruction never executed
+	data                       // data read or written
+	unused                     // neither executed nor accessed as data
+)
+
+// coverLine is a single line of a coverage report.
+type coverLine struct {
+	Addr  int
+	Words string
+	Text  string
+	Kind  coverKind
+	Count int    // execution count for instructions
+	Notes string // branches or data access details
+}
+
+// CoverageSummary are the statistics of a coverage report.
+type CoverageSummary struct {
+	Instructions int // count of instructions found
+	Covered      int // count of instructions executed
+	Branches     int // count of conditional jumps directions found
+	Taken        int // count of conditional jumps directions taken
+}
+
+// NewCoverage create an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		Executed: make(map[int]int),
+		Reads:    make(map[int]int),
+		Writes:   make(map[int]int),
+		Taken:    make(map[int]int),
+		NotTaken: make(map[int]int),
+	}
+}
+
+// Cover enable the coverage recording of the Computer into cov. Recording is
+// disabled when cov is nil.
+func (c *Computer) Cover(cov *Coverage) {
+	c.cov = cov
+}
+
+// branch record the direction of the conditional jump at addr.
+func (cov *Coverage) branch(addr int, jump bool) {
+	if jump {
+		cov.Taken[addr]++
+	} else {
+		cov.NotTaken[addr]++
+	}
+}
+
+// accessed tells whether the address was read or written as data.
+func (cov *Coverage) accessed(addr int) bool {
+	return cov.Reads[addr] > 0 || cov.Writes[addr] > 0
+}
+
+// lines build the coverage report lines for the given program. Addresses
+// executed as instructions are disassembled, the others are either data when
+// they were accessed as such, never executed instructions when they can be
+// decoded, or unused memory. The addresses past the program that were
+// executed or accessed, e.g. by programs extending their memory, are reported
+// too with their initial zero value.
+func (cov *Coverage) lines(program []Intcode) ([]coverLine, CoverageSummary) {
+	var lines []coverLine
+	var sum CoverageSummary
+	mem := program
+	if end := cov.end(); end > len(program) {
+		mem = make([]Intcode, end)
+		copy(mem, program)
+	}
+	Disassemble(mem, func(addr int, in Instruction, ok bool) (bool, bool) {
+		l := coverLine{Addr: addr, Kind: unused, Text: "(data)"}
+		code := false
+		switch {
+		case cov.Executed[addr] > 0:
+			l.Kind = covered
+			l.Count = cov.Executed[addr]
+			if ok {
+				code = true
+				l.Text = in.String()
+			} else {
+				// self-modifying code, the program's instruction differ from
+				// the executed one.
+				l.Text = "(modified)"
+			}
+			sum.Instructions++
+			sum.Covered++
+			if ok && (in.Opcode == JumpIfTrue || in.Opcode == JumpIfFalse) {
+				t, nt := cov.Taken[addr], cov.NotTaken[addr]
+				l.Notes = fmt.Sprintf("taken=%d not-taken=%d", t, nt)
+				sum.Branches += 2
+				if t > 0 {
+					sum.Taken++
+				}
+				if nt > 0 {
+					sum.Taken++
+				}
+			}
+		case cov.accessed(addr):
+			l.Kind = data
+			l.Notes = fmt.Sprintf("read=%d written=%d", cov.Reads[addr], cov.Writes[addr])
+		case ok && cov.untouched(addr+1, addr+in.Size()):
+			l.Kind = uncovered
+			l.Text = in.String()
+			code = true
+			sum.Instructions++
+			if in.Opcode == JumpIfTrue || in.Opcode == JumpIfFalse {
+				sum.Branches += 2
+			}
+		}
+		size := 1
+		if code {
+			size = in.Size()
+		}
+		if l.Kind == unused && addr >= len(program) {
+			return code, true // skip the memory extension gaps
+		}
+		words := make([]string, size)
+		for i, w := range mem[addr : addr+size] {
+			words[i] = fmt.Sprintf("%d", w)
+		}
+		l.Words = strings.Join(words, ",")
+		lines = append(lines, l)
+		return code, true
+	})
+	return lines, sum
+}
+
+// end returns the address following the highest address executed or
+// accessed as data.
+func (cov *Coverage) end() int {
+	end := 0
+	for _, m := range []map[int]int{cov.Executed, cov.Reads, cov.Writes} {
+		for addr := range m {
+			if addr >= end {
+				end = addr + 1
+			}
+		}
+	}
+	return end
+}
+
+// untouched tells whether every address in [from, to) was neither executed
+// nor accessed as data.
+func (cov *Coverage) untouched(from, to int) bool {
+	for addr := from; addr < to; addr++ {
+		if cov.Executed[addr] > 0 || cov.accessed(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements Stringer for CoverageSummary.
+func (s CoverageSummary) String() string {
+	return fmt.Sprintf("instructions: %d/%d (%.1f%%), branches: %d/%d (%.1f%%)",
+		s.Covered, s.Instructions, percent(s.Covered, s.Instructions),
+		s.Taken, s.Branches, percent(s.Taken, s.Branches))
+}
+
+// WriteText write the coverage report of the given program into w as an
+// annotated listing. Each line is prefixed by the execution count of the
+// instruction, never executed instructions being flagged with a bang.
+func (cov *Coverage) WriteText(w io.Writer, program []Intcode) error {
+	lines, sum := cov.lines(program)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n", sum)
+	for _, l := range lines {
+		var count string
+		switch l.Kind {
+		case covered:
+			count = fmt.Sprintf("%8d |", l.Count)
+		case uncovered:
+			count = fmt.Sprintf("%8d !", 0)
+		default:
+			count = fmt.Sprintf("%8s |", "")
+		}
+		line := fmt.Sprintf("%s %04d: %-24s %-32s %s", count, l.Addr, l.Words, l.Text, l.Notes)
+		buf.WriteString(strings.TrimRight(line, " "))
+		buf.WriteString("\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// coverHTML is the template used by WriteHTML.
+var coverHTML = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Intcode coverage</title>
+<style>
+body { background: #000; color: #ccc; font-family: monospace; }
+.covered { color: #2ecc40; }
+.uncovered { color: #ff4136; }
+.data { color: #7fdbff; }
+.unused { color: #555; }
+</style>
+</head>
+<body>
+<p>{{.Summary}}</p>
+<pre>
+{{- range .Lines}}
+<span class="{{.Class}}" title="{{.Notes}}">{{printf "%8s" .Count}} {{printf "%04d" .Addr}}: {{printf "%-24s" .Words}} {{printf "%-32s" .Text}} {{.Notes}}</span>
+{{- end}}
+</pre>
+</body>
+</html>
+`))
+
+// WriteHTML write the coverage report of the given program into w as an HTML
+// page, highlighting executed instructions in green, never executed
+// instructions in red and data in blue.
+func (cov *Coverage) WriteHTML(w io.Writer, program []Intcode) error {
+	type htmlLine struct {
+		coverLine
+		Class string
+		Count string
+	}
+	lines, sum := cov.lines(program)
+	page := struct {
+		Summary CoverageSummary
+		Lines   []htmlLine
+	}{Summary: sum}
+	for _, l := range lines {
+		hl := htmlLine{coverLine: l}
+		switch l.Kind {
+		case covered:
+			hl.Class, hl.Count = "covered", fmt.Sprintf("%d", l.Count)
+		case uncovered:
+			hl.Class, hl.Count = "uncovered", "0"
+		case data:
+			hl.Class = "data"
+		case unused:
+			hl.Class = "unused"
+		}
+		page.Lines = append(page.Lines, hl)
+	}
+	return coverHTML.Execute(w, page)
+}
+
+// percent returns n / total as a percentage, 100 when total is zero.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(n) / float64(total)
+}
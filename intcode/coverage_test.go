@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	// Write 1 if the input is non-zero, 0 otherwise.
+	program := []Intcode{3, 12, 1005, 12, 9, 104, 0, 99, 0, 104, 1, 99, 0}
+	cov := NewCoverage()
+	c := Computer{input: make(chan Intcode, 1), output: make(chan Intcode, 1)}
+	c.Cover(cov)
+	c.input <- 42
+	if err := c.Execute(program); err != nil {
+		t.Fatalf("Execute() error: %s", err)
+	}
+	<-c.output
+
+	var buf bytes.Buffer
+	if err := cov.WriteText(&buf, program); err != nil {
+		t.Fatalf("WriteText() error: %s", err)
+	}
+	want := strings.Join([]string{
+		"instructions: 4/6 (66.7%), branches: 1/2 (50.0%)",
+		"       1 | 0000: 3,12                     Read [12]",
+		"       1 | 0002: 1005,12,9                JumpIfTrue [12], 9               taken=1 not-taken=0",
+		"       0 ! 0005: 104,0                    Write 0",
+		"       0 ! 0007: 99                       Halt",
+		"         | 0008: 0                        (data)",
+		"       1 | 0009: 104,1                    Write 1",
+		"       1 | 0011: 99                       Halt",
+		"         | 0012: 0                        (data)                           read=1 written=1",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := cov.WriteHTML(&buf, program); err != nil {
+		t.Fatalf("WriteHTML() error: %s", err)
+	}
+	if !strings.Contains(buf.String(), `<span class="uncovered"`) {
+		t.Errorf("WriteHTML() does not highlight uncovered instructions")
+	}
+}
+
+func TestCoverageBeyondProgram(t *testing.T) {
+	// Write 2 + 3 through the address 20, past the end of the program.
+	program := []Intcode{1101, 2, 3, 20, 4, 20, 99}
+	cov := NewCoverage()
+	c := Computer{output: make(chan Intcode, 1)}
+	c.Cover(cov)
+	if err := c.Execute(program); err != nil {
+		t.Fatalf("Execute() error: %s", err)
+	}
+	<-c.output
+
+	var buf bytes.Buffer
+	if err := cov.WriteText(&buf, program); err != nil {
+		t.Fatalf("WriteText() error: %s", err)
+	}
+	want := strings.Join([]string{
+		"instructions: 3/3 (100.0%), branches: 0/0 (100.0%)",
+		"       1 | 0000: 1101,2,3,20              Add 2, 3, [20]",
+		"       1 | 0004: 4,20                     Write [20]",
+		"       1 | 0006: 99                       Halt",
+		"         | 0020: 0                        (data)                           read=1 written=1",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
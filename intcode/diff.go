@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RegisterDiff is a register having a different value in two states.
+type RegisterDiff struct {
+	Name     string
+	Old, New int
+}
+
+// RangeDiff is a range of consecutive memory addresses [From, To) having
+// different values in two states.
+type RangeDiff struct {
+	From, To int
+	Old, New []Intcode
+}
+
+// StateDiff is the difference between two Computer states.
+type StateDiff struct {
+	Registers     []RegisterDiff
+	Ranges        []RangeDiff
+	before, after Snapshot
+}
+
+// Diff compare the two given states. Memory beyond the end of a Snapshot is
+// considered to be zero, so that states of Computers having expanded their
+// memory differently can be compared. Changed addresses separated by at most
+// gap unchanged addresses are merged into the same RangeDiff.
+func Diff(before, after Snapshot, gap int) StateDiff {
+	d := StateDiff{before: before, after: after}
+	for _, r := range []RegisterDiff{
+		{"pc", before.PC, after.PC},
+		{"rbo", before.RBO, after.RBO},
+		{"steps", before.Steps, after.Steps},
+	} {
+		if r.Old != r.New {
+			d.Registers = append(d.Registers, r)
+		}
+	}
+
+	size := len(before.Memory)
+	if len(after.Memory) > size {
+		size = len(after.Memory)
+	}
+	from, to := -1, -1 // the current range
+	flush := func() {
+		if from >= 0 {
+			d.Ranges = append(d.Ranges, RangeDiff{
+				From: from,
+				To:   to,
+				Old:  at(before.Memory, from, to),
+				New:  at(after.Memory, from, to),
+			})
+		}
+	}
+	for addr := 0; addr < size; addr++ {
+		if word(before.Memory, addr) == word(after.Memory, addr) {
+			continue
+		}
+		if from >= 0 && addr-to > gap {
+			flush()
+			from = -1
+		}
+		if from < 0 {
+			from = addr
+		}
+		to = addr + 1
+	}
+	flush()
+	return d
+}
+
+// Empty tells whether the two states are the same.
+func (d StateDiff) Empty() bool {
+	return len(d.Registers) == 0 && len(d.Ranges) == 0
+}
+
+// String implements Stringer for StateDiff. Registers are displayed first,
+// then every changed memory range in a unified diff like format. When a range
+// contained code in the old state, the disassembly of the old and new
+// instructions is displayed instead of the raw memory.
+func (d StateDiff) String() string {
+	var buf bytes.Buffer
+	for _, r := range d.Registers {
+		fmt.Fprintf(&buf, "%s: %d -> %d\n", r.Name, r.Old, r.New)
+	}
+	for _, r := range d.Ranges {
+		oldCode, isCode := listing(d.before.Memory, r.From, r.To)
+		if isCode {
+			newCode, _ := listing(d.after.Memory, r.From, r.To)
+			fmt.Fprintf(&buf, "@@ %d,%d @@ code\n", r.From, r.To)
+			for _, l := range oldCode {
+				fmt.Fprintf(&buf, "- %s\n", l)
+			}
+			for _, l := range newCode {
+				fmt.Fprintf(&buf, "+ %s\n", l)
+			}
+		} else {
+			fmt.Fprintf(&buf, "@@ %d,%d @@\n", r.From, r.To)
+			fmt.Fprintf(&buf, "- %04d: %s\n", r.From, join(r.Old))
+			fmt.Fprintf(&buf, "+ %04d: %s\n", r.From, join(r.New))
+		}
+	}
+	return buf.String()
+}
+
+// WriteTo write the StateDiff's String representation into w. It returns the
+// number of bytes written and any write error encountered.
+func (d StateDiff) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, d.String())
+	return int64(n), err
+}
+
+// listing disassemble mem and returns the instructions overlapping the
+// addresses [from, to), along with true when at least one of them decoded
+// into an instruction. Instructions boundaries are found by Disassemble from
+// the start of mem, so the listing is a best effort when code and data are
+// mixed up.
+func listing(mem []Intcode, from, to int) ([]string, bool) {
+	var lines []string
+	code := false
+	Disassemble(mem, func(addr int, in Instruction, ok bool) (bool, bool) {
+		if addr >= to {
+			return false, false
+		}
+		size, text := 1, "(data)"
+		if ok {
+			size, text = in.Size(), in.String()
+		}
+		if addr+size > from {
+			code = code || ok
+			words := join(mem[addr : addr+size])
+			lines = append(lines, fmt.Sprintf("%04d: %-24s %s", addr, words, text))
+		}
+		return ok, true
+	})
+	return lines, code
+}
+
+// word returns mem[addr], zero when addr is out of mem.
+func word(mem []Intcode, addr int) Intcode {
+	if addr < len(mem) {
+		return mem[addr]
+	}
+	return 0
+}
+
+// at returns a copy of mem[from:to], addresses out of mem being zero.
+func at(mem []Intcode, from, to int) []Intcode {
+	xs := make([]Intcode, to-from)
+	for i := range xs {
+		xs[i] = word(mem, from+i)
+	}
+	return xs
+}
+
+// join returns the comma separated representation of xs.
+func join(xs []Intcode) string {
+	s := make([]string, len(xs))
+	for i, x := range xs {
+		s[i] = fmt.Sprintf("%d", x)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := Snapshot{
+		PC:     0,
+		Memory: []Intcode{1101, 1, 2, 9, 99, 0, 0, 0, 0, 0},
+	}
+	after := Snapshot{
+		PC:     4,
+		Steps:  1,
+		Memory: []Intcode{1102, 1, 2, 9, 99, 0, 0, 0, 0, 3, 7},
+	}
+
+	t.Run("ranges", func(t *testing.T) {
+		d := Diff(before, after, 0)
+		regs := []RegisterDiff{{"pc", 0, 4}, {"steps", 0, 1}}
+		if !reflect.DeepEqual(d.Registers, regs) {
+			t.Errorf("Registers = %v; want %v", d.Registers, regs)
+		}
+		ranges := []RangeDiff{
+			{From: 0, To: 1, Old: []Intcode{1101}, New: []Intcode{1102}},
+			{From: 9, To: 11, Old: []Intcode{0, 0}, New: []Intcode{3, 7}},
+		}
+		if !reflect.DeepEqual(d.Ranges, ranges) {
+			t.Errorf("Ranges = %v; want %v", d.Ranges, ranges)
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		d := Diff(before, after, 8)
+		if len(d.Ranges) != 1 || d.Ranges[0].From != 0 || d.Ranges[0].To != 11 {
+			t.Errorf("Ranges = %v; want a single [0, 11) range", d.Ranges)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if d := Diff(before, before, 0); !d.Empty() {
+			t.Errorf("Diff() of the same state = %v; want empty", d)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		want := strings.Join([]string{
+			"pc: 0 -> 4",
+			"steps: 0 -> 1",
+			"@@ 0,1 @@ code",
+			"- 0000: 1101,1,2,9               Add 1, 2, [9]",
+			"+ 0000: 1102,1,2,9               Mult 1, 2, [9]",
+			"@@ 9,11 @@",
+			"- 0009: 0,0",
+			"+ 0009: 3,7",
+			"",
+		}, "\n")
+		if got := Diff(before, after, 0).String(); got != want {
+			t.Errorf("String() =\n%s\nwant\n%s", got, want)
+		}
+	})
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats
+const (
+	// Auto detect the format of a program being read, see Decoder.
+	Auto Format = iota
+	// Text is the puzzle input format, i.e. comma separated decimal Intcode.
+	// Whitespace is allowed between Intcode and a # start a comment running
+	// until the end of the line.
+	Text
+	// Binary is a compact format starting with the binaryMagic header followed
+	// by every Intcode as a signed varint.
+	Binary
+	// JSON is a JSON array of integers.
+	JSON
+)
+
+// binaryMagic is the header of the Binary format.
+const binaryMagic = "\x00ICB"
+
+// Format is an Intcode program encoding.
+type Format uint8
+
+// SyntaxError is returned by Decoder when a program can not be decoded.
+type SyntaxError struct {
+	// Line and Column locate the invalid Intcode in the Text format, starting
+	// at 1. Both are zero for the other formats.
+	Line, Column int
+	// Offset is the byte offset of the error in the Binary and JSON formats.
+	Offset int64
+	// Index is the index of the invalid Intcode in the program.
+	Index int
+	// Token is the invalid Intcode as found in the Text and JSON formats.
+	Token string
+	Err   error
+}
+
+// Decoder read an Intcode program one Intcode at a time, so that large
+// programs are never held in memory in their encoded form.
+type Decoder struct {
+	r      *bufio.Reader
+	format Format
+	json   *json.Decoder
+	index  int   // index of the next Intcode
+	line   int   // current line in the Text format
+	column int   // column of the last read byte in the Text format
+	comma  bool  // whether the last Intcode was followed by a comma
+	offset int64 // offset of the next byte in the Binary format
+}
+
+// String implements Stringer for Format.
+func (f Format) String() string {
+	switch f {
+	case Auto:
+		return "auto"
+	case Text:
+		return "text"
+	case Binary:
+		return "binary"
+	case JSON:
+		return "json"
+	default:
+		return "<invalid format>"
+	}
+}
+
+// ParseFormat returns the Format named s, as returned by Format.String.
+func ParseFormat(s string) (Format, error) {
+	for f := Auto; f <= JSON; f++ {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return Auto, fmt.Errorf("unknown format %q", s)
+}
+
+// Error implements the error interface for SyntaxError.
+func (e *SyntaxError) Error() string {
+	var at string
+	if e.Line > 0 {
+		at = fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	} else {
+		at = fmt.Sprintf("offset %d", e.Offset)
+	}
+	if e.Token != "" {
+		return fmt.Sprintf("%s: Intcode #%d %q: %s", at, e.Index, e.Token, e.Err)
+	}
+	return fmt.Sprintf("%s: Intcode #%d: %s", at, e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// NewDecoder create a Decoder reading a program in the given Format from r.
+// When format is Auto, the format is detected from the first bytes of r.
+func NewDecoder(r io.Reader, format Format) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), format: format, line: 1}
+}
+
+// Next returns the next Intcode of the program. It returns io.EOF at the end
+// of the program, a *SyntaxError when the program is malformed and any other
+// read error encountered.
+func (d *Decoder) Next() (Intcode, error) {
+	if d.format == Auto {
+		if err := d.detect(); err != nil {
+			return 0, err
+		}
+	}
+	var ic Intcode
+	var err error
+	switch d.format {
+	case Text:
+		ic, err = d.text()
+	case Binary:
+		ic, err = d.binary()
+	case JSON:
+		ic, err = d.array()
+	default:
+		return 0, fmt.Errorf("invalid format %d", d.format)
+	}
+	if err == nil {
+		d.index++
+	}
+	return ic, err
+}
+
+// detect set the Decoder's format from the first bytes of the program.
+func (d *Decoder) detect() error {
+	d.format = Text
+	if b, err := d.r.Peek(len(binaryMagic)); err == nil && string(b) == binaryMagic {
+		d.format = Binary
+		return nil
+	}
+	// Skip leading whitespace to find a JSON array.
+	for {
+		b, err := d.r.Peek(1)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			d.read()
+		case '[':
+			d.format = JSON
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// read returns the next byte from the Text format, tracking the position.
+func (d *Decoder) read() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b == '\n' {
+		d.line++
+		d.column = 0
+	} else {
+		d.column++
+	}
+	return b, nil
+}
+
+// text decode the next Intcode in the Text format.
+func (d *Decoder) text() (Intcode, error) {
+	var token strings.Builder
+	line, column := 0, 0
+	for {
+		b, err := d.read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		switch {
+		case b == '#':
+			// comment, skip until the end of the line.
+			for b != '\n' {
+				if b, err = d.read(); err == io.EOF {
+					break
+				} else if err != nil {
+					return 0, err
+				}
+			}
+			if token.Len() > 0 {
+				d.comma = false
+				return d.token(token.String(), line, column)
+			}
+		case b == ',':
+			if token.Len() == 0 && (d.comma || d.index == 0) {
+				return 0, &SyntaxError{Line: d.line, Column: d.column, Index: d.index, Err: errors.New("missing Intcode")}
+			}
+			d.comma = true
+			if token.Len() > 0 {
+				return d.token(token.String(), line, column)
+			}
+		case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+			if token.Len() > 0 {
+				d.comma = false
+				return d.token(token.String(), line, column)
+			}
+		default:
+			if token.Len() == 0 {
+				line, column = d.line, d.column
+			}
+			token.WriteByte(b)
+		}
+	}
+	if token.Len() > 0 {
+		return d.token(token.String(), line, column)
+	}
+	return 0, io.EOF
+}
+
+// token convert the given Text token found at line and column into an
+// Intcode.
+func (d *Decoder) token(s string, line, column int) (Intcode, error) {
+	ic, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		var nerr *strconv.NumError
+		if errors.As(err, &nerr) {
+			err = nerr.Err
+		}
+		return 0, &SyntaxError{Line: line, Column: column, Index: d.index, Token: s, Err: err}
+	}
+	return Intcode(ic), nil
+}
+
+// binary decode the next Intcode in the Binary format.
+func (d *Decoder) binary() (Intcode, error) {
+	if d.offset == 0 {
+		magic := make([]byte, len(binaryMagic))
+		if _, err := io.ReadFull(d.r, magic); err != nil || string(magic) != binaryMagic {
+			return 0, &SyntaxError{Err: errors.New("missing binary header")}
+		}
+		d.offset += int64(len(magic))
+	}
+	r := &countingReader{r: d.r}
+	x, err := binary.ReadVarint(r)
+	offset := d.offset
+	d.offset += r.n
+	switch {
+	case err == io.EOF:
+		return 0, io.EOF
+	case err == io.ErrUnexpectedEOF:
+		return 0, &SyntaxError{Offset: offset, Index: d.index, Err: errors.New("truncated varint")}
+	case err != nil && r.err == nil:
+		// ReadVarint only fails by itself on overflow.
+		return 0, &SyntaxError{Offset: offset, Index: d.index, Err: err}
+	case err != nil:
+		return 0, err
+	}
+	return Intcode(x), nil
+}
+
+// array decode the next Intcode in the JSON format.
+func (d *Decoder) array() (Intcode, error) {
+	if d.json == nil {
+		d.json = json.NewDecoder(d.r)
+		d.json.UseNumber()
+		if t, err := d.json.Token(); err != nil || t != json.Delim('[') {
+			return 0, &SyntaxError{Offset: d.json.InputOffset(), Err: errors.New("expected a JSON array")}
+		}
+	}
+	offset := d.json.InputOffset()
+	t, err := d.json.Token()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, &SyntaxError{Offset: offset, Index: d.index, Err: err}
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		if t == ']' {
+			return 0, io.EOF
+		}
+		return 0, &SyntaxError{Offset: offset, Index: d.index, Token: t.String(), Err: errors.New("expected an integer")}
+	case json.Number:
+		ic, err := strconv.ParseInt(t.String(), 10, 64)
+		if err != nil {
+			var nerr *strconv.NumError
+			if errors.As(err, &nerr) {
+				err = nerr.Err
+			}
+			return 0, &SyntaxError{Offset: offset, Index: d.index, Token: t.String(), Err: err}
+		}
+		return Intcode(ic), nil
+	default:
+		return 0, &SyntaxError{Offset: offset, Index: d.index, Token: fmt.Sprint(t), Err: errors.New("expected an integer")}
+	}
+}
+
+// Load read a whole program in the given Format from r. It returns the
+// program and any read or decoding error encountered.
+func Load(r io.Reader, format Format) ([]Intcode, error) {
+	var prog []Intcode
+	d := NewDecoder(r, format)
+	for {
+		ic, err := d.Next()
+		if err == io.EOF {
+			return prog, nil
+		} else if err != nil {
+			return nil, err
+		}
+		prog = append(prog, ic)
+	}
+}
+
+// Store write the given program in the given Format into w. The Text format
+// is used when format is Auto. It returns any write error encountered.
+func Store(w io.Writer, program []Intcode, format Format) error {
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	switch format {
+	case Auto, Text, JSON:
+		sep, end := ",", "\n"
+		if format == JSON {
+			bw.WriteByte('[')
+			end = "]\n"
+		}
+		for i, ic := range program {
+			if i > 0 {
+				bw.WriteString(sep)
+			}
+			bw.Write(strconv.AppendInt(buf[:0], int64(ic), 10))
+		}
+		bw.WriteString(end)
+	case Binary:
+		bw.WriteString(binaryMagic)
+		for _, ic := range program {
+			n := binary.PutVarint(buf[:], int64(ic))
+			bw.Write(buf[:n])
+		}
+	default:
+		return fmt.Errorf("invalid format %d", format)
+	}
+	return bw.Flush()
+}
+
+// countingReader is an io.ByteReader counting the bytes read and keeping
+// track of the read error.
+type countingReader struct {
+	r   io.ByteReader
+	n   int64
+	err error
+}
+
+// ReadByte implements io.ByteReader for countingReader.
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	c.err = err
+	return b, err
+}
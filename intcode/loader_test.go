@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	want := []Intcode{1, -2, 3, 99, 1 << 40}
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{"puzzle input", "1,-2,3,99,1099511627776\n", Auto},
+		{"no final newline", "1,-2,3,99,1099511627776", Text},
+		{"whitespace", "  1, -2,\n\t3 ,99\r\n,1099511627776 ", Auto},
+		{"comments", "# header\n1,-2, # add\n3,99, # halt\n1099511627776 # data", Text},
+		{"json", " [1, -2, 3, 99, 1099511627776]", Auto},
+		{"binary", "\x00ICB\x02\x03\x06\xc6\x01\x80\x80\x80\x80\x80\x40", Auto},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Load(strings.NewReader(tc.input), tc.format)
+			if err != nil {
+				t.Fatalf("Load() error: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+		want   SyntaxError
+	}{
+		{
+			name:  "invalid token",
+			input: "1,2\n 3,x4",
+			want:  SyntaxError{Line: 2, Column: 4, Index: 3, Token: "x4", Err: strconv.ErrSyntax},
+		},
+		{
+			name:  "out of range",
+			input: "99999999999999999999",
+			want:  SyntaxError{Line: 1, Column: 1, Token: "99999999999999999999", Err: strconv.ErrRange},
+		},
+		{
+			name:  "missing Intcode",
+			input: "1,,2",
+			want:  SyntaxError{Line: 1, Column: 3, Index: 1},
+		},
+		{
+			name:  "json float",
+			input: "[1,2.5]",
+			want:  SyntaxError{Offset: 2, Index: 1, Token: "2.5", Err: strconv.ErrSyntax},
+		},
+		{
+			name:   "truncated binary",
+			input:  "\x00ICB\x02\x80",
+			format: Binary,
+			want:   SyntaxError{Offset: 5, Index: 1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tc.input), tc.format)
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Load() error = %v; want a *SyntaxError", err)
+			}
+			got := *serr
+			if tc.want.Err == nil {
+				got.Err = nil // only check the position
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Load() error = %#v; want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	program := []Intcode{109, -1, 204, 1, 99, 1 << 50, -(1 << 50)}
+	for _, format := range []Format{Text, Binary, JSON} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Store(&buf, program, format); err != nil {
+				t.Fatalf("Store() error: %s", err)
+			}
+			d := NewDecoder(&buf, Auto)
+			for i, want := range program {
+				got, err := d.Next()
+				if err != nil {
+					t.Fatalf("Next() error: %s", err)
+				}
+				if got != want {
+					t.Errorf("Next() #%d = %d; want %d", i, got, want)
+				}
+			}
+			if _, err := d.Next(); err != io.EOF {
+				t.Errorf("Next() at the end of the program error = %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// main load the Intcode program file given as argument and run it in an
+// interactive REPL session. When the first argument is "diff", the states
+// given as argument are compared instead. When it is "convert", the program
+// given as argument is written in another format.
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			diff(os.Args[2:])
+			return
+		case "convert":
+			convert(os.Args[2:])
+			return
+		}
+	}
+	format := flag.String("format", "auto", "the program `format`: auto, text, binary or json")
+	ascii := flag.Bool("ascii", false, "read and display the program input and output as ASCII text")
+	cover := flag.String("cover", "", "write a coverage report into `file` on exit, as HTML when its name ends with .html")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-ascii] [-cover file] [-format format] program\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s diff [-gap n] old new\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s convert [-from format] [-to format] in out\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	program, err := loadProgram(flag.Arg(0), *format)
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+
+	repl := NewREPL(program, os.Stdin, os.Stdout)
+	repl.ASCII = *ascii
+	var cov *Coverage
+	if *cover != "" {
+		cov = NewCoverage()
+		repl.c.Cover(cov)
+	}
+	if err := repl.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if cov != nil {
+		if err := writeCoverage(*cover, cov, program); err != nil {
+			log.Fatalf("coverage error: %s\n", err)
+		}
+	}
+}
+
+// diff compare the two Computer states given in args and display their
+// differences. Each state is either a snapshot saved from the REPL or an
+// Intcode program.
+func diff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	gap := fs.Int("gap", 0, "merge changed ranges separated by at most `n` unchanged addresses")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s diff [-gap n] old new\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	before, err := loadState(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	after, err := loadState(fs.Arg(1))
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	d := Diff(before, after, *gap)
+	if _, err := d.WriteTo(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if !d.Empty() {
+		os.Exit(1)
+	}
+}
+
+// loadState read a Computer state from the file at path, either a JSON
+// encoded Snapshot or an Intcode program. It returns the state and any read
+// or parsing error encountered.
+func loadState(path string) (Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	b, err := r.Peek(1)
+	if err == nil && b[0] == '{' {
+		return ReadSnapshot(r)
+	}
+	program, err := Load(r, Auto)
+	return Snapshot{Memory: program}, err
+}
+
+// convert read the program given in args and write it in another format.
+func convert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := fs.String("from", "auto", "the input program `format`: auto, text, binary or json")
+	to := fs.String("to", "text", "the output program `format`: text, binary or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s convert [-from format] [-to format] in out\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	format, err := ParseFormat(*to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	program, err := loadProgram(fs.Arg(0), *from)
+	if err != nil {
+		log.Fatalf("input error: %s\n", err)
+	}
+	f, err := os.Create(fs.Arg(1))
+	if err != nil {
+		log.Fatalf("output error: %s\n", err)
+	}
+	if err := Store(f, program, format); err != nil {
+		log.Fatalf("output error: %s\n", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("output error: %s\n", err)
+	}
+}
+
+// loadProgram read the program in the file at path, encoded in the format
+// named name. It returns the program and any read or decoding error
+// encountered.
+func loadProgram(path, name string) ([]Intcode, error) {
+	format, err := ParseFormat(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	program, err := Load(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return program, nil
+}
+
+// writeCoverage write the coverage report of the given program into the file
+// at path. The report is written as HTML when path ends with .html, as text
+// otherwise.
+func writeCoverage(path string, cov *Coverage, program []Intcode) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, ".html") {
+		err = cov.WriteHTML(f, program)
+	} else {
+		err = cov.WriteText(f, program)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// replHelp is displayed by the :help meta-command.
+const replHelp = `Anything not starting with a colon is given as input to the program, either
+as comma or space separated Intcode or as ASCII text in ASCII mode.
+Meta-commands:
+  :help                  display this help
+  :regs                  display the registers
+  :mem [addr [count]]    display the memory
+  :dis [addr]            disassemble the memory around addr (default pc)
+  :ascii                 toggle the ASCII mode
+  :reset                 restart the program
+  :save file             save the Computer's state into file
+  :load file             restore the Computer's state from file
+  :quit                  exit
+`
+
+// REPL is an interactive session running an Intcode program. The program is
+// executed until it needs input, at which point the user is prompted.
+type REPL struct {
+	// ASCII tells whether the program's input and output are ASCII text or
+	// Intcode.
+	ASCII   bool
+	c       Computer
+	program []Intcode
+	pending []Intcode // input not yet read by the program
+	halted  bool
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// NewREPL create a REPL session running the given program, reading the user
+// lines from r and displaying everything on w.
+func NewREPL(program []Intcode, r io.Reader, w io.Writer) *REPL {
+	repl := &REPL{
+		program: program,
+		scanner: bufio.NewScanner(r),
+		out:     w,
+	}
+	repl.reset()
+	return repl
+}
+
+// Run the REPL session until the user quit or the end of the user input. It
+// returns any read error encountered.
+func (r *REPL) Run() error {
+	for {
+		r.resume()
+		if r.halted {
+			fmt.Fprint(r.out, "> ")
+		} else {
+			fmt.Fprint(r.out, "? ")
+		}
+		if !r.scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return r.scanner.Err()
+		}
+		line := r.scanner.Text()
+		if strings.HasPrefix(line, ":") {
+			if quit := r.command(strings.Fields(line[1:])); quit {
+				return nil
+			}
+			continue
+		}
+		if r.halted {
+			fmt.Fprintln(r.out, "the program has halted, try :reset")
+			continue
+		}
+		if err := r.feed(line); err != nil {
+			fmt.Fprintf(r.out, "input error: %s\n", err)
+		}
+	}
+}
+
+// reset restart the program.
+func (r *REPL) reset() {
+	r.c.boot(r.program)
+	r.rewire()
+}
+
+// rewire setup the Computer's input and output, discarding everything not
+// yet read by either the program or the user.
+func (r *REPL) rewire() {
+	r.c.input = make(chan Intcode, 1)
+	r.c.output = make(chan Intcode, 1)
+	r.pending = nil
+	r.halted = false
+}
+
+// feed parse the given user line as input for the program.
+func (r *REPL) feed(line string) error {
+	if r.ASCII {
+		for _, b := range []byte(line) {
+			r.pending = append(r.pending, Intcode(b))
+		}
+		r.pending = append(r.pending, '\n')
+		return nil
+	}
+	var in []Intcode
+	for _, s := range strings.FieldsFunc(line, func(c rune) bool { return c == ',' || c == ' ' }) {
+		ic, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		in = append(in, Intcode(ic))
+	}
+	r.pending = append(r.pending, in...)
+	return nil
+}
+
+// resume execute the program until it halts, fails or reaches a Read
+// instruction while there is no pending input.
+func (r *REPL) resume() {
+	for !r.halted {
+		opcode, _, _, _, err := r.c.instruction()
+		if err == nil && opcode == Read {
+			if len(r.pending) == 0 {
+				return
+			}
+			r.c.input <- r.pending[0]
+			r.pending = r.pending[1:]
+		}
+		halted, err := r.c.step()
+		r.flush()
+		switch {
+		case err != nil:
+			r.halted = true
+			fmt.Fprintf(r.out, "execution error: %s", r.c.fault(err).Dump())
+		case halted:
+			r.halted = true
+			fmt.Fprintln(r.out, "the program has halted")
+		default:
+			r.c.steps++
+		}
+	}
+}
+
+// flush display every output from the program.
+func (r *REPL) flush() {
+	for {
+		select {
+		case o := <-r.c.output:
+			if r.ASCII && o >= 0 && o < 128 {
+				fmt.Fprintf(r.out, "%c", rune(o))
+			} else {
+				fmt.Fprintf(r.out, "%d\n", o)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// command execute the given meta-command. It returns true when the session
+// should end, false otherwise.
+func (r *REPL) command(args []string) bool {
+	if len(args) == 0 {
+		args = []string{"help"}
+	}
+	// ints parse the meta-command integer arguments.
+	ints := func() ([]int, error) {
+		xs := make([]int, len(args)-1)
+		for i, s := range args[1:] {
+			x, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			xs[i] = x
+		}
+		return xs, nil
+	}
+	switch args[0] {
+	case "quit", "q":
+		return true
+	case "help", "h":
+		fmt.Fprint(r.out, replHelp)
+	case "regs":
+		fmt.Fprintf(r.out, "pc=%d rbo=%d steps=%d halted=%v pending=%v\n",
+			r.c.pc, r.c.rbo, r.c.steps, r.halted, r.pending)
+	case "mem":
+		xs, err := ints()
+		if err != nil {
+			fmt.Fprintf(r.out, ":mem error: %s\n", err)
+			break
+		}
+		from, count := 0, len(r.c.mem)
+		if len(xs) > 0 {
+			from = xs[0]
+			count -= from
+		}
+		if len(xs) > 1 {
+			count = xs[1]
+		}
+		r.dump(from, count)
+	case "dis":
+		xs, err := ints()
+		if err != nil {
+			fmt.Fprintf(r.out, ":dis error: %s\n", err)
+			break
+		}
+		addr := r.c.pc
+		if len(xs) > 0 {
+			addr = xs[0]
+		}
+		fmt.Fprint(r.out, Disassembly(r.c.mem, addr, 5, 5))
+	case "ascii":
+		r.ASCII = !r.ASCII
+		fmt.Fprintf(r.out, "ASCII mode: %v\n", r.ASCII)
+	case "reset":
+		r.reset()
+	case "save":
+		if len(args) != 2 {
+			fmt.Fprintln(r.out, "usage: :save file")
+			break
+		}
+		if err := r.save(args[1]); err != nil {
+			fmt.Fprintf(r.out, ":save error: %s\n", err)
+		}
+	case "load":
+		if len(args) != 2 {
+			fmt.Fprintln(r.out, "usage: :load file")
+			break
+		}
+		if err := r.load(args[1]); err != nil {
+			fmt.Fprintf(r.out, ":load error: %s\n", err)
+		}
+	default:
+		fmt.Fprintf(r.out, "unknown meta-command :%s, try :help\n", args[0])
+	}
+	return false
+}
+
+// dump display count Intcode from the memory starting at the from address.
+func (r *REPL) dump(from, count int) {
+	const perLine = 10
+	to := from + count
+	if from < 0 {
+		from = 0
+	}
+	if to > len(r.c.mem) {
+		to = len(r.c.mem)
+	}
+	for addr := from; addr < to; addr += perLine {
+		end := addr + perLine
+		if end > to {
+			end = to
+		}
+		words := make([]string, end-addr)
+		for i, w := range r.c.mem[addr:end] {
+			words[i] = strconv.FormatInt(int64(w), 10)
+		}
+		fmt.Fprintf(r.out, "%04d: %s\n", addr, strings.Join(words, ","))
+	}
+}
+
+// save write the Computer's state into the given file.
+func (r *REPL) save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteSnapshot(f, r.c.Snapshot()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load restore the Computer's state from the given file.
+func (r *REPL) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := ReadSnapshot(f)
+	if err != nil {
+		return err
+	}
+	r.c.Restore(s)
+	r.rewire()
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	// Read two Intcode and Write their sum, forever.
+	program := []Intcode{3, 13, 3, 14, 1, 13, 14, 15, 4, 15, 1105, 1, 0, 0, 0, 0}
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+	session := strings.Join([]string{
+		"1, 2",
+		"40",
+		":save " + snapshot,
+		"2",
+		":regs",
+		":load " + snapshot,
+		"3",
+		":reset",
+		"x",
+		"5 5",
+		":quit",
+		"6 6",
+	}, "\n")
+
+	var out bytes.Buffer
+	repl := NewREPL(program, strings.NewReader(session), &out)
+	if err := repl.Run(); err != nil {
+		t.Fatalf("Run() error: %s", err)
+	}
+	want := strings.Join([]string{
+		"? 3",
+		"? ? ? 42",
+		"? pc=0 rbo=0 steps=10 halted=false pending=[]",
+		"? ? 43",
+		"? ? input error: strconv.ParseInt: parsing \"x\": invalid syntax",
+		"? 10",
+		"? ",
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("session output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestREPLASCII(t *testing.T) {
+	// Read a character and Write it back until a newline is read, then halt.
+	program := []Intcode{3, 100, 4, 100, 1008, 100, 10, 101, 1006, 101, 0, 99}
+	var out bytes.Buffer
+	repl := NewREPL(program, strings.NewReader("hello\n42\n"), &out)
+	repl.ASCII = true
+	if err := repl.Run(); err != nil {
+		t.Fatalf("Run() error: %s", err)
+	}
+	want := "? hello\nthe program has halted\n> the program has halted, try :reset\n> \n"
+	if got := out.String(); got != want {
+		t.Errorf("session output:\n%q\nwant:\n%q", got, want)
+	}
+}
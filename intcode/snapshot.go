@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Snapshot is a copy of a Computer's state, i.e. its registers and memory.
+type Snapshot struct {
+	PC     int       `json:"pc"`
+	RBO    int       `json:"rbo"`
+	Steps  int       `json:"steps"`
+	Memory []Intcode `json:"memory"`
+}
+
+// Snapshot returns a copy of the Computer's current state.
+func (c *Computer) Snapshot() Snapshot {
+	mem := make([]Intcode, len(c.mem))
+	copy(mem, c.mem)
+	return Snapshot{PC: c.pc, RBO: c.rbo, Steps: c.steps, Memory: mem}
+}
+
+// Restore set the Computer's state to a copy of the given Snapshot.
+func (c *Computer) Restore(s Snapshot) {
+	c.mem = make([]Intcode, len(s.Memory))
+	copy(c.mem, s.Memory)
+	c.pc = s.PC
+	c.rbo = s.RBO
+	c.steps = s.Steps
+}
+
+// WriteSnapshot encode the given Snapshot as JSON into w.
+func WriteSnapshot(w io.Writer, s Snapshot) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// ReadSnapshot decode a JSON encoded Snapshot from r. It returns the decoded
+// Snapshot and any read or decoding error encountered.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var s Snapshot
+	err := json.NewDecoder(r).Decode(&s)
+	return s, err
+}
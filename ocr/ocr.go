@@ -0,0 +1,216 @@
+// Package ocr implements the recognition of the capital letters drawn in a
+// block font, six pixels high and usually four pixels wide, by some puzzles
+// like day08 and day11.
+package ocr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Unknown is the character used in place of unrecognized glyphs.
+const Unknown = '?'
+
+// Bitmap is a monochrome image indexed by row then column, a true pixel
+// being lit.
+type Bitmap [][]bool
+
+// Glyph is a character image segmented from a Bitmap.
+type Glyph struct {
+	// Column is the Glyph's first column in the segmented Bitmap.
+	Column int
+	Bitmap Bitmap
+}
+
+// UnrecognizedError is returned by Recognize when some glyphs are not in the
+// font.
+type UnrecognizedError struct {
+	// Text is the recognized text, the unrecognized glyphs being replaced by
+	// Unknown.
+	Text   string
+	Glyphs []Glyph // the unrecognized glyphs
+}
+
+// font is the block font, every glyph being drawn with # for lit pixels.
+var font = map[rune]string{
+	'A': ".##./#..#/#..#/####/#..#/#..#",
+	'B': "###./#..#/###./#..#/#..#/###.",
+	'C': ".##./#..#/#.../#.../#..#/.##.",
+	'E': "####/#.../###./#.../#.../####",
+	'F': "####/#.../###./#.../#.../#...",
+	'G': ".##./#..#/#.../#.##/#..#/.###",
+	'H': "#..#/#..#/####/#..#/#..#/#..#",
+	'I': ".###/..#./..#./..#./..#./.###",
+	'J': "..##/...#/...#/...#/#..#/.##.",
+	'K': "#..#/#.#./##../#.#./#.#./#..#",
+	'L': "#.../#.../#.../#.../#.../####",
+	'O': ".##./#..#/#..#/#..#/#..#/.##.",
+	'P': "###./#..#/#..#/###./#.../#...",
+	'R': "###./#..#/#..#/###./#.#./#..#",
+	'S': ".###/#.../#.../.##./...#/###.",
+	'U': "#..#/#..#/#..#/#..#/#..#/.##.",
+	'Y': "#...#/#...#/.#.#./..#../..#../..#..",
+	'Z': "####/...#/..#./.#../#.../####",
+}
+
+// glyphs map the String representation of every trimmed font glyph to its
+// character.
+var glyphs = make(map[string]rune)
+
+func init() {
+	for r, s := range font {
+		b := Parse(strings.ReplaceAll(s, "/", "\n"), '#')
+		glyphs[b.trim().String()] = r
+	}
+}
+
+// Parse returns the Bitmap drawn in s, one line per row, where lit pixels are
+// drawn with the lit rune.
+func Parse(s string, lit rune) Bitmap {
+	var b Bitmap
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		var row []bool
+		for _, r := range line {
+			row = append(row, r == lit)
+		}
+		b = append(b, row)
+	}
+	return b
+}
+
+// Width returns the count of columns of the Bitmap, i.e. the size of its
+// longest row.
+func (b Bitmap) Width() int {
+	w := 0
+	for _, row := range b {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
+
+// At tells whether the pixel at column x and row y is lit, pixels outside of
+// the Bitmap being off.
+func (b Bitmap) At(x, y int) bool {
+	return y >= 0 && y < len(b) && x >= 0 && x < len(b[y]) && b[y][x]
+}
+
+// String implements Stringer for Bitmap, drawing lit pixels with # and the
+// others with a dot.
+func (b Bitmap) String() string {
+	var buf bytes.Buffer
+	w := b.Width()
+	for y := range b {
+		for x := 0; x < w; x++ {
+			if b.At(x, y) {
+				buf.WriteByte('#')
+			} else {
+				buf.WriteByte('.')
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// crop returns the part of the Bitmap within columns [x0, x1) and rows
+// [y0, y1).
+func (b Bitmap) crop(x0, y0, x1, y1 int) Bitmap {
+	c := make(Bitmap, y1-y0)
+	for y := range c {
+		c[y] = make([]bool, x1-x0)
+		for x := range c[y] {
+			c[y][x] = b.At(x0+x, y0+y)
+		}
+	}
+	return c
+}
+
+// blankRow tells whether every pixel of the row y is off.
+func (b Bitmap) blankRow(y int) bool {
+	for x := 0; x < b.Width(); x++ {
+		if b.At(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// blankColumn tells whether every pixel of the column x is off.
+func (b Bitmap) blankColumn(x int) bool {
+	for y := range b {
+		if b.At(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// trim returns the Bitmap without its leading and trailing blank rows and
+// columns.
+func (b Bitmap) trim() Bitmap {
+	x0, y0, x1, y1 := 0, 0, b.Width(), len(b)
+	for y0 < y1 && b.blankRow(y0) {
+		y0++
+	}
+	for y1 > y0 && b.blankRow(y1-1) {
+		y1--
+	}
+	for x0 < x1 && b.blankColumn(x0) {
+		x0++
+	}
+	for x1 > x0 && b.blankColumn(x1-1) {
+		x1--
+	}
+	return b.crop(x0, y0, x1, y1)
+}
+
+// Segment split the Bitmap into glyphs separated by blank columns. Leading
+// and trailing blank rows are ignored, and every Glyph is trimmed.
+func Segment(b Bitmap) []Glyph {
+	var gs []Glyph
+	w := b.Width()
+	for x := 0; x < w; {
+		if b.blankColumn(x) {
+			x++
+			continue
+		}
+		start := x
+		for x < w && !b.blankColumn(x) {
+			x++
+		}
+		gs = append(gs, Glyph{Column: start, Bitmap: b.crop(start, 0, x, len(b)).trim()})
+	}
+	return gs
+}
+
+// Recognize returns the text drawn in the Bitmap. It returns an
+// *UnrecognizedError when some glyphs are not in the font.
+func Recognize(b Bitmap) (string, error) {
+	var text strings.Builder
+	var unknown []Glyph
+	for _, g := range Segment(b) {
+		r, ok := glyphs[g.Bitmap.String()]
+		if !ok {
+			r = Unknown
+			unknown = append(unknown, g)
+		}
+		text.WriteRune(r)
+	}
+	if len(unknown) > 0 {
+		return text.String(), &UnrecognizedError{Text: text.String(), Glyphs: unknown}
+	}
+	return text.String(), nil
+}
+
+// Error implements the error interface for UnrecognizedError.
+func (e *UnrecognizedError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d unrecognized glyphs in %q", len(e.Glyphs), e.Text)
+	for _, g := range e.Glyphs {
+		fmt.Fprintf(&buf, "\nat column %d:\n%s", g.Column, strings.TrimRight(g.Bitmap.String(), "\n"))
+	}
+	return buf.String()
+}
@@ -0,0 +1,79 @@
+package ocr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecognize(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{
+			name: "day08",
+			image: strings.Join([]string{
+				"###..#..#.####.###..###..",
+				"#..#.#..#....#.#..#.#..#.",
+				"#..#.#..#...#..###..#..#.",
+				"###..#..#..#...#..#.###..",
+				"#.#..#..#.#....#..#.#....",
+				"#..#..##..####.###..#....",
+			}, "\n"),
+			want: "RUZBP",
+		},
+		{
+			name: "blank rows and wide letters",
+			image: strings.Join([]string{
+				"..............",
+				"..#...#..###..",
+				"..#...#.#.....",
+				"...#.#..#.....",
+				"....#....##...",
+				"....#......#..",
+				"....#...###...",
+				"..............",
+			}, "\n"),
+			want: "YS",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Recognize(Parse(tc.image, '#'))
+			if err != nil {
+				t.Fatalf("Recognize() error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("Recognize() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecognizeUnknown(t *testing.T) {
+	image := strings.Join([]string{
+		"#..#.#...#",
+		"#..#.##.##",
+		"####.#.#.#",
+		"#..#.#...#",
+		"#..#.#...#",
+		"#..#.#...#",
+	}, "\n")
+	got, err := Recognize(Parse(image, '#'))
+	if got != "H?" {
+		t.Errorf("Recognize() = %q; want %q", got, "H?")
+	}
+	var uerr *UnrecognizedError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("Recognize() error = %v; want an *UnrecognizedError", err)
+	}
+	if len(uerr.Glyphs) != 1 || uerr.Glyphs[0].Column != 5 {
+		t.Fatalf("unrecognized glyphs = %v; want one at column 5", uerr.Glyphs)
+	}
+	want := "#...#\n##.##\n#.#.#\n#...#\n#...#\n#...#\n"
+	if s := uerr.Glyphs[0].Bitmap.String(); s != want {
+		t.Errorf("unrecognized glyph =\n%s\nwant\n%s", s, want)
+	}
+}